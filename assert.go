@@ -0,0 +1,249 @@
+package wisent
+
+import (
+	"maps"
+	"mime"
+	"net/http"
+	"os"
+	"path/filepath"
+	"regexp"
+	"slices"
+	"strings"
+	"testing"
+	"time"
+)
+
+// AssertResponseError is a testing helper method that checks if response error is empty.
+func (w *Wisent) AssertResponseError(tb testing.TB, err error) {
+	tb = w.assertionTB(tb)
+	if err != nil {
+		tb.Fatalf("Error performing the request: %v", err)
+	}
+}
+
+// AssertResponseStatusCode is a testing helper method that compares response status code.
+func (w *Wisent) AssertResponseStatusCode(tb testing.TB, expected int, resp *http.Response) {
+	tb = w.assertionTB(tb)
+	if resp.StatusCode != expected {
+		tb.Fatalf("Incorrect status code, got: %v, want: %v", resp.StatusCode, expected)
+	}
+}
+
+// AssertResponseStatusCodeInRange is a testing helper method that checks if response status code is within [min, max].
+func (w *Wisent) AssertResponseStatusCodeInRange(tb testing.TB, min, max int, resp *http.Response) {
+	tb = w.assertionTB(tb)
+	if resp.StatusCode < min || resp.StatusCode > max {
+		tb.Fatalf("Status code out of range, got: %v, want: [%v, %v]", resp.StatusCode, min, max)
+	}
+}
+
+// AssertResponseStatusCodeOneOf is a testing helper method that checks if response status code is one of the provided codes.
+func (w *Wisent) AssertResponseStatusCodeOneOf(tb testing.TB, resp *http.Response, codes ...int) {
+	tb = w.assertionTB(tb)
+	if !slices.Contains(codes, resp.StatusCode) {
+		tb.Fatalf("Incorrect status code, got: %v, want one of: %v", resp.StatusCode, codes)
+	}
+}
+
+// AssertResponseBody is a testing helper method that compares response body.
+func (w *Wisent) AssertResponseBody(tb testing.TB, expected string, resp *http.Response) {
+	tb = w.assertionTB(tb)
+	actualBody, err := readBody(resp)
+	if err != nil {
+		tb.Fatalf("Error reading response body: %v", err)
+	}
+
+	if string(actualBody) != expected {
+		tb.Fatalf("Body mismatch\nExpected: %s\nActual: %s", expected, actualBody)
+	}
+}
+
+// AssertResponseTime is a testing helper method that checks if the response completed within maxDuration
+// since start, which should be recorded right before sending the request.
+// With Test.AssertResponseLatency, the start is time.Now().Add(-latency).
+func (w *Wisent) AssertResponseTime(tb testing.TB, maxDuration time.Duration, start time.Time, resp *http.Response) {
+	tb = w.assertionTB(tb)
+	if elapsed := time.Since(start); elapsed > maxDuration {
+		tb.Fatalf("Response took too long, got: %v, want at most: %v", elapsed, maxDuration)
+	}
+}
+
+// AssertResponseHeader is a testing helper method that compares the value of a response header.
+// The key lookup is case-insensitive and surrounding whitespace of the actual value is ignored.
+func (w *Wisent) AssertResponseHeader(tb testing.TB, key, expected string, resp *http.Response) {
+	tb = w.assertionTB(tb)
+	actual := strings.TrimSpace(resp.Header.Get(key))
+	if actual != expected {
+		tb.Fatalf("Incorrect header %q, got: %q, want: %q", key, actual, expected)
+	}
+}
+
+// AssertResponseNoHeader is a testing helper method that checks if a response header is absent,
+// e.g. to make sure X-Powered-By is not exposed. Keys are case-insensitive, so lowercase HTTP/2 names match too.
+func (w *Wisent) AssertResponseNoHeader(tb testing.TB, key string, resp *http.Response) {
+	tb = w.assertionTB(tb)
+	if values := resp.Header.Values(key); len(values) > 0 {
+		tb.Fatalf("Header %q is present, got: %q", key, strings.Join(values, ", "))
+	}
+}
+
+// AssertResponseHeaderContains is a testing helper method that checks if a response header contains a substring.
+func (w *Wisent) AssertResponseHeaderContains(tb testing.TB, key, substring string, resp *http.Response) {
+	tb = w.assertionTB(tb)
+	actual := resp.Header.Get(key)
+	if !strings.Contains(actual, substring) {
+		tb.Fatalf("Header %q does not contain %q, got: %q", key, substring, actual)
+	}
+}
+
+// AssertResponseBodyContains is a testing helper method that checks if response body contains a substring.
+func (w *Wisent) AssertResponseBodyContains(tb testing.TB, substring string, resp *http.Response) {
+	tb = w.assertionTB(tb)
+	actualBody, err := readBody(resp)
+	if err != nil {
+		tb.Fatalf("Error reading response body: %v", err)
+	}
+
+	if !strings.Contains(string(actualBody), substring) {
+		tb.Fatalf("Body does not contain substring\nExpected substring: %s\nActual: %s", substring, actualBody)
+	}
+}
+
+// AssertResponseBodyMatchesRegex is a testing helper method that checks if response body matches a regular expression.
+// An invalid pattern fails the test immediately.
+func (w *Wisent) AssertResponseBodyMatchesRegex(tb testing.TB, pattern string, resp *http.Response) {
+	tb = w.assertionTB(tb)
+	re, err := regexp.Compile(pattern)
+	if err != nil {
+		tb.Fatalf("Error compiling pattern %q: %v", pattern, err)
+	}
+	w.AssertResponseBodyMatchesRegexp(tb, re, resp)
+}
+
+// AssertResponseBodyMatchesRegexp is a testing helper method that checks if response body matches a compiled
+// regular expression. It allows reusing patterns compiled with regexp.MustCompile, e.g. in package-level variables.
+func (w *Wisent) AssertResponseBodyMatchesRegexp(tb testing.TB, re *regexp.Regexp, resp *http.Response) {
+	tb = w.assertionTB(tb)
+	actualBody, err := readBody(resp)
+	if err != nil {
+		tb.Fatalf("Error reading response body: %v", err)
+	}
+
+	if !re.Match(actualBody) {
+		tb.Fatalf("Body does not match pattern\nPattern: %s\nActual: %s", re, actualBody)
+	}
+}
+
+// AssertResponseBodyMatchesGolden is a testing helper method that compares response body with the content of a golden file.
+// On mismatch, a unified diff is reported. When the UPDATE_GOLDEN environment variable is set to "true",
+// the golden file is overwritten with the actual body instead.
+func (w *Wisent) AssertResponseBodyMatchesGolden(tb testing.TB, goldenPath string, resp *http.Response) {
+	tb = w.assertionTB(tb)
+	actualBody, err := readBody(resp)
+	if err != nil {
+		tb.Fatalf("Error reading response body: %v", err)
+	}
+
+	if os.Getenv("UPDATE_GOLDEN") == "true" {
+		if err := os.MkdirAll(filepath.Dir(goldenPath), 0o755); err != nil {
+			tb.Fatalf("Error creating golden file directory: %v", err)
+		}
+		if err := os.WriteFile(goldenPath, actualBody, 0o644); err != nil {
+			tb.Fatalf("Error writing golden file: %v", err)
+		}
+		return
+	}
+
+	expected, err := os.ReadFile(goldenPath)
+	if err != nil {
+		tb.Fatalf("Error reading golden file: %v", err)
+	}
+	if diff := unifiedDiff(goldenPath, "response", string(expected), string(actualBody)); diff != "" {
+		tb.Fatalf("Body does not match golden file\n%s", diff)
+	}
+}
+
+// AssertResponseContentType is a testing helper method that compares the media type of the Content-Type header.
+// If expected has no parameters, parameters of the actual header (e.g. charset) are ignored.
+// Otherwise, parameters must match as well.
+func (w *Wisent) AssertResponseContentType(tb testing.TB, expected string, resp *http.Response) {
+	tb = w.assertionTB(tb)
+	expectedType, expectedParams, err := mime.ParseMediaType(expected)
+	if err != nil {
+		tb.Fatalf("Error parsing expected content type %q: %v", expected, err)
+	}
+	raw := resp.Header.Get("Content-Type")
+	actualType, actualParams, err := mime.ParseMediaType(raw)
+	if err != nil {
+		tb.Fatalf("Error parsing Content-Type header %q: %v", raw, err)
+	}
+
+	if actualType != expectedType || (len(expectedParams) > 0 && !maps.Equal(actualParams, expectedParams)) {
+		tb.Fatalf("Incorrect content type, got: %q, want: %q", raw, expected)
+	}
+}
+
+// AssertResponseCookieExists is a testing helper method that checks if the response sets a cookie with the given name.
+func (w *Wisent) AssertResponseCookieExists(tb testing.TB, name string, resp *http.Response) {
+	tb = w.assertionTB(tb)
+	findCookie(tb, name, resp)
+}
+
+// AssertResponseCookieValue is a testing helper method that compares the value of a cookie set by the response.
+func (w *Wisent) AssertResponseCookieValue(tb testing.TB, name, value string, resp *http.Response) {
+	tb = w.assertionTB(tb)
+	if cookie := findCookie(tb, name, resp); cookie.Value != value {
+		tb.Fatalf("Incorrect value of cookie %q, got: %q, want: %q", name, cookie.Value, value)
+	}
+}
+
+// AssertResponseCookieAttribute is a testing helper method that checks an attribute of a cookie set by the response.
+// The attribute is either a flag, "Secure" or "HttpOnly", or a key-value pair, e.g. "SameSite=Strict" or "Path=/".
+// Attribute names are case-insensitive, and so are values, except for Path.
+func (w *Wisent) AssertResponseCookieAttribute(tb testing.TB, name, attribute string, resp *http.Response) {
+	tb = w.assertionTB(tb)
+	cookie := findCookie(tb, name, resp)
+	key, expected, _ := strings.Cut(attribute, "=")
+
+	var actual string
+	equal := strings.EqualFold
+	switch strings.ToLower(strings.TrimSpace(key)) {
+	case "secure":
+		if !cookie.Secure {
+			tb.Fatalf("Cookie %q is not Secure: %s", name, cookie)
+		}
+		return
+	case "httponly":
+		if !cookie.HttpOnly {
+			tb.Fatalf("Cookie %q is not HttpOnly: %s", name, cookie)
+		}
+		return
+	case "samesite":
+		actual = map[http.SameSite]string{
+			http.SameSiteLaxMode:    "Lax",
+			http.SameSiteStrictMode: "Strict",
+			http.SameSiteNoneMode:   "None",
+		}[cookie.SameSite]
+	case "path":
+		actual, equal = cookie.Path, func(a, b string) bool { return a == b }
+	case "domain":
+		actual = cookie.Domain
+	default:
+		tb.Fatalf("Unsupported cookie attribute %q", attribute)
+	}
+
+	if !equal(actual, strings.TrimSpace(expected)) {
+		tb.Fatalf("Incorrect attribute %q of cookie %q, got: %q, want: %q", key, name, actual, expected)
+	}
+}
+
+// findCookie returns the cookie with the given name set by the response, failing the test if there is none.
+func findCookie(tb testing.TB, name string, resp *http.Response) *http.Cookie {
+	for _, cookie := range resp.Cookies() {
+		if cookie.Name == name {
+			return cookie
+		}
+	}
+	tb.Fatalf("Cookie %q not found in response", name)
+	return nil
+}
@@ -0,0 +1,374 @@
+package wisent
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"maps"
+	"net/http"
+	"reflect"
+	"regexp"
+	"slices"
+	"strings"
+	"testing"
+	"time"
+)
+
+// AssertResponseBodyJSONArraySorted is a testing helper method that checks if response body is a JSON array
+// of objects, sorted by the given field.
+// Values are compared numerically for numbers and lexicographically for strings.
+func (w *Wisent) AssertResponseBodyJSONArraySorted(tb testing.TB, field string, ascending bool, resp *http.Response) {
+	tb = w.assertionTB(tb)
+	values := fieldValues(tb, readJSONArray(tb, resp), field)
+	for i := 1; i < len(values); i++ {
+		cmp, err := compareJSONValues(values[i-1], values[i])
+		if err != nil {
+			tb.Fatalf("Error comparing field %q at index %d: %v", field, i, err)
+		}
+		if (ascending && cmp > 0) || (!ascending && cmp < 0) {
+			tb.Fatalf("Array not sorted by %q (ascending: %v), index %d: %v, index %d: %v", field, ascending, i-1, values[i-1], i, values[i])
+		}
+	}
+}
+
+// AssertResponseJSON is a testing helper method that checks if response body is semantically equal to the expected JSON.
+// Key order and whitespace are ignored, array order is not. A key with null value is not equal to a missing key.
+// On mismatch, every differing path is reported.
+func (w *Wisent) AssertResponseJSON(tb testing.TB, expected string, resp *http.Response) {
+	tb = w.assertionTB(tb)
+	expectedValue, err := unmarshalJSON([]byte(expected))
+	if err != nil {
+		tb.Fatalf("Error decoding expected JSON: %v", err)
+	}
+	actualValue := readJSON(tb, resp)
+
+	if diffs := diffJSON("$", expectedValue, actualValue); len(diffs) > 0 {
+		tb.Fatalf("JSON mismatch\n%s", strings.Join(diffs, "\n"))
+	}
+}
+
+// AssertResponseBodyJSONUniqueArray is a testing helper method that checks if response body is a JSON array
+// of objects, in which the given field has no duplicate values.
+//
+// Deprecated: Use AssertResponseBodyJSONArrayDistinct, which checks the same.
+func (w *Wisent) AssertResponseBodyJSONUniqueArray(tb testing.TB, field string, resp *http.Response) {
+	w.AssertResponseBodyJSONArrayDistinct(tb, field, resp)
+}
+
+// AssertResponseBodyJSONNested is a testing helper method that compares a nested field of the response body JSON object.
+// The field is addressed with a dot-separated path, e.g. "user.address.city".
+// Strings are compared as is, other values are compared in their JSON form (e.g. "42", "true", "null").
+func (w *Wisent) AssertResponseBodyJSONNested(tb testing.TB, dotPath, expected string, resp *http.Response) {
+	tb = w.assertionTB(tb)
+	v := readJSON(tb, resp)
+	for _, key := range strings.Split(dotPath, ".") {
+		obj, ok := v.(map[string]any)
+		if !ok {
+			tb.Fatalf("Cannot get %q from non-object value %s at path %q", key, formatJSON(v), dotPath)
+		}
+		if v, ok = obj[key]; !ok {
+			tb.Fatalf("Field %q not found at path %q", key, dotPath)
+		}
+	}
+
+	if actual := jsonValueString(v); actual != expected {
+		tb.Fatalf("Incorrect value at path %q, got: %s, want: %s", dotPath, actual, expected)
+	}
+}
+
+// AssertResponseBodyJSONArrayContainsAll is a testing helper method that checks if response body is a JSON array
+// containing all expected elements, in any order.
+// Expected elements can be any values that encode to JSON, e.g. strings, numbers, maps or structs.
+func (w *Wisent) AssertResponseBodyJSONArrayContainsAll(tb testing.TB, expected []interface{}, resp *http.Response) {
+	tb = w.assertionTB(tb)
+	actual := readJSONArray(tb, resp)
+	for _, elem := range expected {
+		expectedValue, err := normalizeJSON(elem)
+		if err != nil {
+			tb.Fatalf("Error encoding expected element %v: %v", elem, err)
+		}
+		if !slices.ContainsFunc(actual, func(v any) bool { return len(diffJSON("$", expectedValue, v)) == 0 }) {
+			tb.Fatalf("Array does not contain %s\nActual: %s", formatJSON(expectedValue), formatJSON(actual))
+		}
+	}
+}
+
+// AssertResponseBodyJSONArrayExactlyN is a testing helper method that checks if response body is a JSON array
+// with exactly n elements.
+func (w *Wisent) AssertResponseBodyJSONArrayExactlyN(tb testing.TB, n int, resp *http.Response) {
+	tb = w.assertionTB(tb)
+	if arr := readJSONArray(tb, resp); len(arr) != n {
+		tb.Fatalf("Incorrect number of array elements, got: %v, want: %v", len(arr), n)
+	}
+}
+
+// AssertResponseBodyJSONArrayMinElement is a testing helper method that checks if response body is a JSON array
+// of numbers, none of which is lower than minValue.
+func (w *Wisent) AssertResponseBodyJSONArrayMinElement(tb testing.TB, minValue float64, resp *http.Response) {
+	tb = w.assertionTB(tb)
+	for i, v := range numbers(tb, readJSONArray(tb, resp)) {
+		if v < minValue {
+			tb.Fatalf("Element at index %d is lower than %v: %v", i, minValue, v)
+		}
+	}
+}
+
+// AssertResponseBodyJSONArrayMaxElement is a testing helper method that checks if response body is a JSON array
+// of numbers, none of which is greater than maxValue.
+func (w *Wisent) AssertResponseBodyJSONArrayMaxElement(tb testing.TB, maxValue float64, resp *http.Response) {
+	tb = w.assertionTB(tb)
+	for i, v := range numbers(tb, readJSONArray(tb, resp)) {
+		if v > maxValue {
+			tb.Fatalf("Element at index %d is greater than %v: %v", i, maxValue, v)
+		}
+	}
+}
+
+// AssertResponseBodyJSONArrayMean is a testing helper method that checks if response body is a JSON array
+// of objects, in which the arithmetic mean of the given numeric field is within [minMean, maxMean].
+func (w *Wisent) AssertResponseBodyJSONArrayMean(tb testing.TB, field string, minMean, maxMean float64, resp *http.Response) {
+	tb = w.assertionTB(tb)
+	values := numbers(tb, fieldValues(tb, readJSONArray(tb, resp), field))
+	if len(values) == 0 {
+		tb.Fatalf("Cannot compute mean of %q for an empty array", field)
+	}
+
+	var sum float64
+	for _, v := range values {
+		sum += v
+	}
+	if mean := sum / float64(len(values)); mean < minMean || mean > maxMean {
+		tb.Fatalf("Mean of %q out of range, got: %v, want: [%v, %v]", field, mean, minMean, maxMean)
+	}
+}
+
+// AssertResponseJSONPath is a testing helper method that compares the value at a JSONPath expression
+// in the response body, e.g. "$.user.name" or "$.items[0].id".
+// Strings are compared as is, other values are compared in their JSON form (e.g. "42", "true", "null").
+func (w *Wisent) AssertResponseJSONPath(tb testing.TB, path, expected string, resp *http.Response) {
+	tb = w.assertionTB(tb)
+	doc := readJSON(tb, resp)
+	v, err := lookupJSONPath(doc, path)
+	if err != nil {
+		tb.Fatalf("Error evaluating path %q: %v\nDocument: %s", path, err, formatJSON(doc))
+	}
+
+	if actual := jsonValueString(v); actual != expected {
+		tb.Fatalf("Incorrect value at path %q, got: %s, want: %s", path, actual, expected)
+	}
+}
+
+// AssertResponseBodyJSONArrayHasSingleElement is a testing helper method that checks if response body is a JSON array
+// with exactly one element, and returns that element.
+func (w *Wisent) AssertResponseBodyJSONArrayHasSingleElement(tb testing.TB, resp *http.Response) interface{} {
+	tb = w.assertionTB(tb)
+	arr := readJSONArray(tb, resp)
+	if len(arr) != 1 {
+		tb.Fatalf("Incorrect number of array elements, got: %v, want: 1", len(arr))
+	}
+	return arr[0]
+}
+
+// AssertResponseBodyJSONPathTyped is a testing helper function that compares the value at a JSONPath expression
+// in the response body with an expected value of type T. It is a function rather than a method,
+// as Go methods cannot have type parameters.
+//
+// The value is converted to T by re-encoding it, so T can be any type that JSON decodes into,
+// e.g. int, float64, bool, string, slices, maps or structs. Values are compared with reflect.DeepEqual.
+func AssertResponseBodyJSONPathTyped[T any](tb testing.TB, path string, expected T, resp *http.Response) {
+	doc := readJSON(tb, resp)
+	v, err := lookupJSONPath(doc, path)
+	if err != nil {
+		tb.Fatalf("Error evaluating path %q: %v\nDocument: %s", path, err, formatJSON(doc))
+	}
+
+	var actual T
+	if err := json.Unmarshal([]byte(formatJSON(v)), &actual); err != nil {
+		tb.Fatalf("Value at path %q is not of type %T: %s", path, expected, formatJSON(v))
+	}
+	if !reflect.DeepEqual(actual, expected) {
+		tb.Fatalf("Incorrect value at path %q, got: %v, want: %v", path, actual, expected)
+	}
+}
+
+// AssertResponseBodyJSONPathExists is a testing helper method that checks if a JSONPath expression
+// resolves to a value in the response body. A null value counts as existing.
+func (w *Wisent) AssertResponseBodyJSONPathExists(tb testing.TB, path string, resp *http.Response) {
+	tb = w.assertionTB(tb)
+	doc := readJSON(tb, resp)
+	if _, err := lookupJSONPath(doc, path); err != nil {
+		tb.Fatalf("Error evaluating path %q: %v\nDocument: %s", path, err, formatJSON(doc))
+	}
+}
+
+// AssertResponseBodyJSONPathNotExists is a testing helper method that checks if a JSONPath expression
+// does not resolve to any value in the response body, not even null, e.g. to make sure internal fields are not leaked.
+func (w *Wisent) AssertResponseBodyJSONPathNotExists(tb testing.TB, path string, resp *http.Response) {
+	tb = w.assertionTB(tb)
+	doc := readJSON(tb, resp)
+	v, err := lookupJSONPath(doc, path)
+	if err == nil {
+		tb.Fatalf("Path %q exists with value: %s", path, formatJSON(v))
+	}
+	if !errors.Is(err, errPathNotFound) {
+		tb.Fatalf("Error evaluating path %q: %v", path, err)
+	}
+}
+
+// AssertResponseBodyJSONArrayDistinct is a testing helper method that checks if response body is a JSON array
+// of objects, in which every value of the given field appears only once.
+func (w *Wisent) AssertResponseBodyJSONArrayDistinct(tb testing.TB, field string, resp *http.Response) {
+	tb = w.assertionTB(tb)
+	values := fieldValues(tb, readJSONArray(tb, resp), field)
+	seen := make(map[string]int, len(values))
+	for i, v := range values {
+		key := formatJSON(v)
+		if first, ok := seen[key]; ok {
+			tb.Fatalf("Duplicate value of %q at indexes %d and %d: %s", field, first, i, key)
+		}
+		seen[key] = i
+	}
+}
+
+// RequireJSONPath is a testing helper method that returns the value at a JSONPath expression or JSON Pointer
+// in the response body, e.g. "$.user.id" or "/user/id". It stops the test if the path does not exist.
+func (w *Wisent) RequireJSONPath(tb testing.TB, path string, resp *http.Response) interface{} {
+	tb = w.assertionTB(tb)
+	doc := readJSON(tb, resp)
+	v, err := lookupJSONPath(doc, path)
+	if err != nil {
+		tb.Fatalf("Error evaluating path %q: %v\nDocument: %s", path, err, formatJSON(doc))
+	}
+	return v
+}
+
+// AssertResponseBodyJSONArrayGroupBy is a testing helper method that checks if response body is a JSON array
+// of objects, in which values of the given field occur exactly as many times as expected.
+// Strings are used as is for group keys, other values in their JSON form.
+func (w *Wisent) AssertResponseBodyJSONArrayGroupBy(tb testing.TB, field string, expectedGroups map[string]int, resp *http.Response) {
+	tb = w.assertionTB(tb)
+	actualGroups := map[string]int{}
+	for _, v := range fieldValues(tb, readJSONArray(tb, resp), field) {
+		actualGroups[jsonValueString(v)]++
+	}
+	if !maps.Equal(actualGroups, expectedGroups) {
+		tb.Fatalf("Incorrect groups of %q, got: %v, want: %v", field, actualGroups, expectedGroups)
+	}
+}
+
+// AssertResponseBodyJSONPaginated is a testing helper method that checks if a paginated response body
+// indicates more pages, based on the given field, e.g. "has_more", "next_cursor" or "$.meta.next_page".
+// Boolean fields are used as is. For other fields, a missing, null or empty value means there are no more pages.
+func (w *Wisent) AssertResponseBodyJSONPaginated(tb testing.TB, field string, hasMore bool, resp *http.Response) {
+	tb = w.assertionTB(tb)
+	doc := readJSON(tb, resp)
+	v, err := lookupJSONPath(doc, field)
+	if err != nil && !errors.Is(err, errPathNotFound) {
+		tb.Fatalf("Error evaluating path %q: %v", field, err)
+	}
+
+	var actual bool
+	switch v := v.(type) {
+	case bool:
+		actual = v
+	case nil:
+		actual = false
+	case string:
+		actual = v != ""
+	default:
+		actual = true
+	}
+	if actual != hasMore {
+		tb.Fatalf("Incorrect pagination by %q, got more pages: %v, want: %v\nDocument: %s", field, actual, hasMore, formatJSON(doc))
+	}
+}
+
+// AssertResponseBodyJSONDate is a testing helper method that checks if the given field of the response body
+// is a date in the "2006-01-02" format, equal to the date of expectedDate. The time and time zone are ignored.
+func (w *Wisent) AssertResponseBodyJSONDate(tb testing.TB, field string, expectedDate time.Time, resp *http.Response) {
+	tb = w.assertionTB(tb)
+	value := readJSONString(tb, resp, field)
+	date, err := time.Parse(time.DateOnly, value)
+	if err != nil {
+		tb.Fatalf("Error parsing date of %q: %v", field, err)
+	}
+	y, m, d := expectedDate.Date()
+	if date.Year() != y || date.Month() != m || date.Day() != d {
+		tb.Fatalf("Incorrect date of %q, got: %v, want: %v", field, value, expectedDate.Format(time.DateOnly))
+	}
+}
+
+var semverRegexp = regexp.MustCompile(`^\d+\.\d+\.\d+`)
+
+// AssertResponseBodyJSONSemver is a testing helper method that checks if the given field of the response body
+// is a version string starting with MAJOR.MINOR.PATCH, e.g. "1.4.2" or "2.0.0-rc.1".
+func (w *Wisent) AssertResponseBodyJSONSemver(tb testing.TB, field string, resp *http.Response) {
+	tb = w.assertionTB(tb)
+	if value := readJSONString(tb, resp, field); !semverRegexp.MatchString(value) {
+		tb.Fatalf("Value of %q is not a semantic version, got: %q", field, value)
+	}
+}
+
+// AssertResponseBodyJSONEnum is a testing helper method that checks if the given field of the response body
+// is a string equal to one of the valid values, e.g. "pending", "active" or "closed".
+func (w *Wisent) AssertResponseBodyJSONEnum(tb testing.TB, field string, validValues []string, resp *http.Response) {
+	tb = w.assertionTB(tb)
+	if value := readJSONString(tb, resp, field); !slices.Contains(validValues, value) {
+		tb.Fatalf("Incorrect value of %q, got: %q, want one of: %q", field, value, validValues)
+	}
+}
+
+// AssertResponseBodyJSONArrayRange is a testing helper method that checks if response body is a JSON array,
+// in which every element in the [start, end) index range satisfies the predicate, e.g. on a page of results.
+// Numbers are passed to the predicate as json.Number.
+func (w *Wisent) AssertResponseBodyJSONArrayRange(tb testing.TB, start, end int, pred func(interface{}) bool, resp *http.Response) {
+	tb = w.assertionTB(tb)
+	arr := readJSONArray(tb, resp)
+	if start < 0 || start > end || end > len(arr) {
+		tb.Fatalf("Incorrect range [%d, %d) for array of length %d", start, end, len(arr))
+	}
+	for i := start; i < end; i++ {
+		if !pred(arr[i]) {
+			tb.Fatalf("Element at index %d does not satisfy the predicate: %s", i, formatJSON(arr[i]))
+		}
+	}
+}
+
+// AssertResponseBodyJSONCompact is a testing helper method that checks if response body is equal to the expected JSON
+// after removing insignificant whitespace from both. Unlike AssertResponseJSON, key order and number formatting matter.
+func (w *Wisent) AssertResponseBodyJSONCompact(tb testing.TB, expectedCompact string, resp *http.Response) {
+	tb = w.assertionTB(tb)
+	body, err := readBody(resp)
+	if err != nil {
+		tb.Fatalf("Error reading response body: %v", err)
+	}
+	var expected, actual bytes.Buffer
+	if err := json.Compact(&expected, []byte(expectedCompact)); err != nil {
+		tb.Fatalf("Error compacting expected JSON: %v", err)
+	}
+	if err := json.Compact(&actual, body); err != nil {
+		tb.Fatalf("Error compacting response body: %v\nBody: %s", err, body)
+	}
+	if actual.String() != expected.String() {
+		tb.Fatalf("Incorrect body, got: %s, want: %s", actual.String(), expected.String())
+	}
+}
+
+// AssertResponseBodyJSONMergePatch is a testing helper method that checks if response body is semantically equal
+// to the original JSON with the JSON Merge Patch (RFC 7396) applied, e.g. in the response to a PATCH request.
+func (w *Wisent) AssertResponseBodyJSONMergePatch(tb testing.TB, originalJSON, patchJSON string, resp *http.Response) {
+	tb = w.assertionTB(tb)
+	original, err := unmarshalJSON([]byte(originalJSON))
+	if err != nil {
+		tb.Fatalf("Error decoding original JSON: %v", err)
+	}
+	patch, err := unmarshalJSON([]byte(patchJSON))
+	if err != nil {
+		tb.Fatalf("Error decoding patch JSON: %v", err)
+	}
+	actual := readJSON(tb, resp)
+
+	if diffs := diffJSON("$", mergePatch(original, patch), actual); len(diffs) > 0 {
+		tb.Fatalf("JSON mismatch\n%s", strings.Join(diffs, "\n"))
+	}
+}
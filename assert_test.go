@@ -0,0 +1,430 @@
+package wisent_test
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+	"regexp"
+	"runtime"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/ttyobiwan/wisent"
+)
+
+// fakeTB records the failure of an assertion instead of failing the test.
+type fakeTB struct {
+	testing.TB
+	failure string
+}
+
+func (f *fakeTB) Helper() {}
+
+func (f *fakeTB) Errorf(format string, args ...any) { f.failure = fmt.Sprintf(format, args...) }
+
+func (f *fakeTB) Fatalf(format string, args ...any) {
+	f.failure = fmt.Sprintf(format, args...)
+	runtime.Goexit()
+}
+
+// runAssertion runs the assertion with a fakeTB and returns the failure message, if any.
+// It uses a goroutine, so that Fatalf can stop the assertion like testing.T.FailNow does.
+func runAssertion(t *testing.T, assert func(tb testing.TB)) string {
+	tb := &fakeTB{TB: t}
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		assert(tb)
+	}()
+	<-done
+	return tb.failure
+}
+
+func response(status int, header http.Header, body string) *http.Response {
+	if header == nil {
+		header = http.Header{}
+	}
+	return &http.Response{StatusCode: status, Header: header, Body: io.NopCloser(strings.NewReader(body))}
+}
+
+func TestAssertions(t *testing.T) {
+	w := wisent.New("http://127.0.0.1")
+	golden := filepath.Join(t.TempDir(), "body.golden")
+	if err := os.WriteFile(golden, []byte("line 1\nline 2\n"), 0o644); err != nil {
+		t.Fatalf("Error writing golden file: %v", err)
+	}
+	cookies := http.Header{"Set-Cookie": {"session=abc; Path=/app; Secure; HttpOnly; SameSite=Strict"}}
+	items := `[{"id":1,"kind":"a","price":10},{"id":2,"kind":"b","price":20},{"id":3,"kind":"a","price":30}]`
+	user := `{"user":{"name":"wisent","age":7,"address":{"city":"Warsaw"}},"version":"1.4.2-rc.1","status":"active","created":"2024-05-01","next":"","has_more":true}`
+
+	tests := []struct {
+		name   string
+		resp   *http.Response
+		assert func(tb testing.TB, resp *http.Response)
+		// failure is a part of the expected failure message, or empty if the assertion should pass.
+		failure string
+	}{
+		{
+			name:   "status code in range",
+			resp:   response(http.StatusNoContent, nil, ""),
+			assert: func(tb testing.TB, resp *http.Response) { w.AssertResponseStatusCodeInRange(tb, 200, 299, resp) },
+		},
+		{
+			name:    "status code out of range",
+			resp:    response(http.StatusNotFound, nil, ""),
+			assert:  func(tb testing.TB, resp *http.Response) { w.AssertResponseStatusCodeInRange(tb, 200, 299, resp) },
+			failure: "Status code out of range, got: 404, want: [200, 299]",
+		},
+		{
+			name:   "status code one of",
+			resp:   response(http.StatusAccepted, nil, ""),
+			assert: func(tb testing.TB, resp *http.Response) { w.AssertResponseStatusCodeOneOf(tb, resp, 200, 202) },
+		},
+		{
+			name:    "status code not one of",
+			resp:    response(http.StatusCreated, nil, ""),
+			assert:  func(tb testing.TB, resp *http.Response) { w.AssertResponseStatusCodeOneOf(tb, resp, 200, 202) },
+			failure: "want one of: [200 202]",
+		},
+		{
+			name: "header contains",
+			resp: response(http.StatusOK, http.Header{"Cache-Control": {"public, max-age=60"}}, ""),
+			assert: func(tb testing.TB, resp *http.Response) {
+				w.AssertResponseHeaderContains(tb, "Cache-Control", "max-age", resp)
+			},
+		},
+		{
+			name: "header does not contain",
+			resp: response(http.StatusOK, http.Header{"Cache-Control": {"no-store"}}, ""),
+			assert: func(tb testing.TB, resp *http.Response) {
+				w.AssertResponseHeaderContains(tb, "Cache-Control", "max-age", resp)
+			},
+			failure: "max-age",
+		},
+		{
+			name:    "unexpected header",
+			resp:    response(http.StatusOK, http.Header{"Server": {"nginx"}}, ""),
+			assert:  func(tb testing.TB, resp *http.Response) { w.AssertResponseNoHeader(tb, "Server", resp) },
+			failure: "Server",
+		},
+		{
+			name: "content type ignores parameters",
+			resp: response(http.StatusOK, http.Header{"Content-Type": {"application/json; charset=utf-8"}}, ""),
+			assert: func(tb testing.TB, resp *http.Response) {
+				w.AssertResponseContentType(tb, "application/json", resp)
+			},
+		},
+		{
+			name: "content type with different parameters",
+			resp: response(http.StatusOK, http.Header{"Content-Type": {"text/plain; charset=latin1"}}, ""),
+			assert: func(tb testing.TB, resp *http.Response) {
+				w.AssertResponseContentType(tb, "text/plain; charset=utf-8", resp)
+			},
+			failure: "Incorrect content type",
+		},
+		{
+			name:   "body contains",
+			resp:   response(http.StatusOK, nil, "hello, wisent"),
+			assert: func(tb testing.TB, resp *http.Response) { w.AssertResponseBodyContains(tb, "wisent", resp) },
+		},
+		{
+			name:    "body does not contain",
+			resp:    response(http.StatusOK, nil, "hello, bison"),
+			assert:  func(tb testing.TB, resp *http.Response) { w.AssertResponseBodyContains(tb, "wisent", resp) },
+			failure: "wisent",
+		},
+		{
+			name:   "body matches regex",
+			resp:   response(http.StatusOK, nil, "order 1234 created"),
+			assert: func(tb testing.TB, resp *http.Response) { w.AssertResponseBodyMatchesRegex(tb, `order \d+`, resp) },
+		},
+		{
+			name: "body does not match regexp",
+			resp: response(http.StatusOK, nil, "order created"),
+			assert: func(tb testing.TB, resp *http.Response) {
+				w.AssertResponseBodyMatchesRegexp(tb, regexp.MustCompile(`order \d+`), resp)
+			},
+			failure: "Body does not match pattern",
+		},
+		{
+			name:   "body matches golden file",
+			resp:   response(http.StatusOK, nil, "line 1\nline 2\n"),
+			assert: func(tb testing.TB, resp *http.Response) { w.AssertResponseBodyMatchesGolden(tb, golden, resp) },
+		},
+		{
+			name:    "body does not match golden file",
+			resp:    response(http.StatusOK, nil, "line 1\nline 3\n"),
+			assert:  func(tb testing.TB, resp *http.Response) { w.AssertResponseBodyMatchesGolden(tb, golden, resp) },
+			failure: "-line 2\n+line 3",
+		},
+		{
+			name: "cookie",
+			resp: response(http.StatusOK, cookies, ""),
+			assert: func(tb testing.TB, resp *http.Response) {
+				w.AssertResponseCookieExists(tb, "session", resp)
+				w.AssertResponseCookieValue(tb, "session", "abc", resp)
+				for _, attribute := range []string{"Secure", "HttpOnly", "samesite=strict", "Path=/app"} {
+					w.AssertResponseCookieAttribute(tb, "session", attribute, resp)
+				}
+			},
+		},
+		{
+			name:    "missing cookie",
+			resp:    response(http.StatusOK, cookies, ""),
+			assert:  func(tb testing.TB, resp *http.Response) { w.AssertResponseCookieExists(tb, "token", resp) },
+			failure: "token",
+		},
+		{
+			name: "incorrect cookie attribute",
+			resp: response(http.StatusOK, cookies, ""),
+			assert: func(tb testing.TB, resp *http.Response) {
+				w.AssertResponseCookieAttribute(tb, "session", "Path=/APP", resp)
+			},
+			failure: `Incorrect attribute "Path" of cookie "session", got: "/app", want: "/APP"`,
+		},
+		{
+			name: "JSON ignores key order",
+			resp: response(http.StatusOK, nil, `{"b":[1,2],"a":"x"}`),
+			assert: func(tb testing.TB, resp *http.Response) {
+				w.AssertResponseJSON(tb, `{"a": "x", "b": [1, 2]}`, resp)
+			},
+		},
+		{
+			name: "JSON mismatch",
+			resp: response(http.StatusOK, nil, `{"a":"x","b":[2,1]}`),
+			assert: func(tb testing.TB, resp *http.Response) {
+				w.AssertResponseJSON(tb, `{"a": "x", "b": [1, 2]}`, resp)
+			},
+			failure: "JSON mismatch",
+		},
+		{
+			name: "JSON paths",
+			resp: response(http.StatusOK, nil, user),
+			assert: func(tb testing.TB, resp *http.Response) {
+				w.AssertResponseJSONPath(tb, "$.user.name", "wisent", resp)
+				w.AssertResponseJSONPath(tb, "$.user.age", "7", resp)
+				w.AssertResponseBodyJSONNested(tb, "user.address.city", "Warsaw", resp)
+				w.AssertResponseBodyJSONPathExists(tb, "$.user.address", resp)
+				w.AssertResponseBodyJSONPathNotExists(tb, "$.user.password", resp)
+				wisent.AssertResponseBodyJSONPathTyped(tb, "$.user.age", 7, resp)
+				if name := w.RequireJSONPath(tb, "/user/name", resp); name != "wisent" {
+					tb.Errorf("Incorrect required value, got: %v", name)
+				}
+			},
+		},
+		{
+			name:    "JSON path with incorrect value",
+			resp:    response(http.StatusOK, nil, user),
+			assert:  func(tb testing.TB, resp *http.Response) { w.AssertResponseJSONPath(tb, "$.user.age", "8", resp) },
+			failure: `Incorrect value at path "$.user.age", got: 7, want: 8`,
+		},
+		{
+			name: "nested field not found",
+			resp: response(http.StatusOK, nil, user),
+			assert: func(tb testing.TB, resp *http.Response) {
+				w.AssertResponseBodyJSONNested(tb, "user.address.zip", "00-001", resp)
+			},
+			failure: `Field "zip" not found`,
+		},
+		{
+			name: "JSON path exists",
+			resp: response(http.StatusOK, nil, user),
+			assert: func(tb testing.TB, resp *http.Response) {
+				w.AssertResponseBodyJSONPathNotExists(tb, "$.user.name", resp)
+			},
+			failure: `Path "$.user.name" exists`,
+		},
+		{
+			name: "typed JSON path of another type",
+			resp: response(http.StatusOK, nil, user),
+			assert: func(tb testing.TB, resp *http.Response) {
+				wisent.AssertResponseBodyJSONPathTyped(tb, "$.user.name", 7, resp)
+			},
+			failure: "is not of type int",
+		},
+		{
+			name: "JSON string fields",
+			resp: response(http.StatusOK, nil, user),
+			assert: func(tb testing.TB, resp *http.Response) {
+				w.AssertResponseBodyJSONSemver(tb, "$.version", resp)
+				w.AssertResponseBodyJSONEnum(tb, "$.status", []string{"pending", "active"}, resp)
+				w.AssertResponseBodyJSONDate(tb, "$.created", time.Date(2024, 5, 1, 23, 0, 0, 0, time.UTC), resp)
+			},
+		},
+		{
+			name: "invalid enum value",
+			resp: response(http.StatusOK, nil, user),
+			assert: func(tb testing.TB, resp *http.Response) {
+				w.AssertResponseBodyJSONEnum(tb, "$.status", []string{"pending", "closed"}, resp)
+			},
+			failure: `Incorrect value of "$.status", got: "active"`,
+		},
+		{
+			name: "incorrect date",
+			resp: response(http.StatusOK, nil, user),
+			assert: func(tb testing.TB, resp *http.Response) {
+				w.AssertResponseBodyJSONDate(tb, "$.created", time.Date(2024, 5, 2, 0, 0, 0, 0, time.UTC), resp)
+			},
+			failure: "want: 2024-05-02",
+		},
+		{
+			name:    "not a semantic version",
+			resp:    response(http.StatusOK, nil, `{"version":"v1"}`),
+			assert:  func(tb testing.TB, resp *http.Response) { w.AssertResponseBodyJSONSemver(tb, "$.version", resp) },
+			failure: "is not a semantic version",
+		},
+		{
+			name: "pagination",
+			resp: response(http.StatusOK, nil, user),
+			assert: func(tb testing.TB, resp *http.Response) {
+				w.AssertResponseBodyJSONPaginated(tb, "$.has_more", true, resp)
+				w.AssertResponseBodyJSONPaginated(tb, "$.next", false, resp)
+				w.AssertResponseBodyJSONPaginated(tb, "$.cursor", false, resp)
+			},
+		},
+		{
+			name: "incorrect pagination",
+			resp: response(http.StatusOK, nil, user),
+			assert: func(tb testing.TB, resp *http.Response) {
+				w.AssertResponseBodyJSONPaginated(tb, "$.has_more", false, resp)
+			},
+			failure: "got more pages: true, want: false",
+		},
+		{
+			name: "compact JSON",
+			resp: response(http.StatusOK, nil, "{\n  \"a\": 1,\n  \"b\": 2\n}"),
+			assert: func(tb testing.TB, resp *http.Response) {
+				w.AssertResponseBodyJSONCompact(tb, `{"a":1, "b":2}`, resp)
+			},
+		},
+		{
+			name: "compact JSON in another key order",
+			resp: response(http.StatusOK, nil, `{"b":2,"a":1}`),
+			assert: func(tb testing.TB, resp *http.Response) {
+				w.AssertResponseBodyJSONCompact(tb, `{"a":1,"b":2}`, resp)
+			},
+			failure: "Incorrect body",
+		},
+		{
+			name: "merge patch",
+			resp: response(http.StatusOK, nil, `{"name":"wisent","tags":["new"]}`),
+			assert: func(tb testing.TB, resp *http.Response) {
+				w.AssertResponseBodyJSONMergePatch(tb, `{"name":"bison","age":7}`, `{"name":"wisent","age":null,"tags":["new"]}`, resp)
+			},
+		},
+		{
+			name: "merge patch not applied",
+			resp: response(http.StatusOK, nil, `{"name":"bison","age":7}`),
+			assert: func(tb testing.TB, resp *http.Response) {
+				w.AssertResponseBodyJSONMergePatch(tb, `{"name":"bison","age":7}`, `{"age":8}`, resp)
+			},
+			failure: "JSON mismatch",
+		},
+		{
+			name: "JSON arrays",
+			resp: response(http.StatusOK, nil, items),
+			assert: func(tb testing.TB, resp *http.Response) {
+				w.AssertResponseBodyJSONArrayExactlyN(tb, 3, resp)
+				w.AssertResponseBodyJSONArrayContainsAll(tb, []interface{}{map[string]any{"id": 3, "kind": "a", "price": 30}}, resp)
+				w.AssertResponseBodyJSONArrayDistinct(tb, "id", resp)
+				w.AssertResponseBodyJSONArraySorted(tb, "price", true, resp)
+				w.AssertResponseBodyJSONArrayGroupBy(tb, "kind", map[string]int{"a": 2, "b": 1}, resp)
+				w.AssertResponseBodyJSONArrayMean(tb, "price", 19.5, 20.5, resp)
+				w.AssertResponseBodyJSONArrayRange(tb, 1, 3, func(v interface{}) bool {
+					price, _ := v.(map[string]any)["price"].(json.Number).Int64()
+					return price >= 20
+				}, resp)
+			},
+		},
+		{
+			name:    "duplicate array values",
+			resp:    response(http.StatusOK, nil, items),
+			assert:  func(tb testing.TB, resp *http.Response) { w.AssertResponseBodyJSONArrayDistinct(tb, "kind", resp) },
+			failure: `Duplicate value of "kind" at indexes 0 and 2`,
+		},
+		{
+			name: "unsorted array",
+			resp: response(http.StatusOK, nil, items),
+			assert: func(tb testing.TB, resp *http.Response) {
+				w.AssertResponseBodyJSONArraySorted(tb, "price", false, resp)
+			},
+			failure: `Array not sorted by "price"`,
+		},
+		{
+			name: "incorrect groups",
+			resp: response(http.StatusOK, nil, items),
+			assert: func(tb testing.TB, resp *http.Response) {
+				w.AssertResponseBodyJSONArrayGroupBy(tb, "kind", map[string]int{"a": 1, "b": 2}, resp)
+			},
+			failure: `Incorrect groups of "kind"`,
+		},
+		{
+			name: "mean out of range",
+			resp: response(http.StatusOK, nil, items),
+			assert: func(tb testing.TB, resp *http.Response) {
+				w.AssertResponseBodyJSONArrayMean(tb, "price", 0, 10, resp)
+			},
+			failure: `Mean of "price" out of range, got: 20`,
+		},
+		{
+			name: "missing array element",
+			resp: response(http.StatusOK, nil, `["a","b"]`),
+			assert: func(tb testing.TB, resp *http.Response) {
+				w.AssertResponseBodyJSONArrayContainsAll(tb, []interface{}{"a", "c"}, resp)
+			},
+			failure: `Array does not contain "c"`,
+		},
+		{
+			name: "array range out of bounds",
+			resp: response(http.StatusOK, nil, `[1,2]`),
+			assert: func(tb testing.TB, resp *http.Response) {
+				w.AssertResponseBodyJSONArrayRange(tb, 0, 3, func(interface{}) bool { return true }, resp)
+			},
+			failure: "Incorrect range [0, 3) for array of length 2",
+		},
+		{
+			name: "array elements within bounds",
+			resp: response(http.StatusOK, nil, `[1,5,3]`),
+			assert: func(tb testing.TB, resp *http.Response) {
+				w.AssertResponseBodyJSONArrayMinElement(tb, 1, resp)
+				w.AssertResponseBodyJSONArrayMaxElement(tb, 5, resp)
+			},
+		},
+		{
+			name:    "array element out of bounds",
+			resp:    response(http.StatusOK, nil, `[1,5,3]`),
+			assert:  func(tb testing.TB, resp *http.Response) { w.AssertResponseBodyJSONArrayMaxElement(tb, 4, resp) },
+			failure: "Element at index 1 is greater than 4: 5",
+		},
+		{
+			name: "single element",
+			resp: response(http.StatusOK, nil, `[{"id":1}]`),
+			assert: func(tb testing.TB, resp *http.Response) {
+				if elem := w.AssertResponseBodyJSONArrayHasSingleElement(tb, resp); elem.(map[string]any)["id"] != json.Number("1") {
+					tb.Errorf("Incorrect element, got: %v", elem)
+				}
+			},
+		},
+		{
+			name:    "more than one element",
+			resp:    response(http.StatusOK, nil, items),
+			assert:  func(tb testing.TB, resp *http.Response) { w.AssertResponseBodyJSONArrayHasSingleElement(tb, resp) },
+			failure: "Incorrect number of array elements, got: 3, want: 1",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			failure := runAssertion(t, func(tb testing.TB) { tt.assert(tb, tt.resp) })
+			switch {
+			case tt.failure == "" && failure != "":
+				t.Fatalf("Expected the assertion to pass, got: %s", failure)
+			case tt.failure != "" && !strings.Contains(failure, tt.failure):
+				t.Fatalf("Incorrect failure, got: %q, want it to contain: %q", failure, tt.failure)
+			}
+		})
+	}
+}
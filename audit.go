@@ -0,0 +1,118 @@
+package wisent
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/csv"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+)
+
+// Formats supported by WithAuditLog.
+const (
+	AuditFormatJSONL = "jsonl"
+	AuditFormatCSV   = "csv"
+)
+
+// auditRecord describes a single request and its response.
+type auditRecord struct {
+	Timestamp        time.Time `json:"timestamp"`
+	Method           string    `json:"method"`
+	URL              string    `json:"url"`
+	StatusCode       int       `json:"status_code"`
+	LatencyMs        float64   `json:"latency_ms"`
+	RequestBodyHash  string    `json:"request_body_hash"`
+	ResponseBodyHash string    `json:"response_body_hash"`
+	Error            string    `json:"error,omitempty"`
+}
+
+// auditLog writes audit records to a writer. It is safe for concurrent use.
+type auditLog struct {
+	mu     sync.Mutex
+	w      io.Writer
+	format string
+	csv    *csv.Writer
+}
+
+func newAuditLog(w io.Writer, format string) (*auditLog, error) {
+	l := &auditLog{w: w, format: format}
+	if format == AuditFormatCSV {
+		l.csv = csv.NewWriter(w)
+		err := l.writeCSV([]string{
+			"timestamp", "method", "url", "status_code", "latency_ms", "request_body_hash", "response_body_hash", "error",
+		})
+		if err != nil {
+			return nil, fmt.Errorf("writing audit header: %w", err)
+		}
+	}
+	return l, nil
+}
+
+func (l *auditLog) write(record auditRecord) error {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	if l.csv != nil {
+		return l.writeCSV([]string{
+			record.Timestamp.Format(time.RFC3339Nano),
+			record.Method,
+			record.URL,
+			strconv.Itoa(record.StatusCode),
+			strconv.FormatFloat(record.LatencyMs, 'f', 3, 64),
+			record.RequestBodyHash,
+			record.ResponseBodyHash,
+			record.Error,
+		})
+	}
+
+	data, err := json.Marshal(record)
+	if err != nil {
+		return fmt.Errorf("encoding audit record: %w", err)
+	}
+	_, err = l.w.Write(append(data, '\n'))
+	return err
+}
+
+// writeCSV writes and flushes a single CSV row, so that records are not lost if the test binary exits.
+func (l *auditLog) writeCSV(row []string) error {
+	if err := l.csv.Write(row); err != nil {
+		return err
+	}
+	l.csv.Flush()
+	return l.csv.Error()
+}
+
+// hashRequestBody returns the hash of the request body, replacing the body with an in-memory copy.
+func hashRequestBody(req *http.Request) (string, error) {
+	body, err := bufferRequestBody(req)
+	if err != nil {
+		return "", err
+	}
+	return hashBytes(body), nil
+}
+
+// bufferRequestBody reads the request body and replaces it with a rewindable copy, returning its content.
+func bufferRequestBody(req *http.Request) ([]byte, error) {
+	if req.Body == nil || req.Body == http.NoBody {
+		return nil, nil
+	}
+	body, err := io.ReadAll(req.Body)
+	req.Body.Close()
+	if err != nil {
+		return nil, fmt.Errorf("reading request body: %w", err)
+	}
+	req.Body = io.NopCloser(bytes.NewReader(body))
+	req.GetBody = func() (io.ReadCloser, error) { return io.NopCloser(bytes.NewReader(body)), nil }
+	return body, nil
+}
+
+func hashBytes(data []byte) string {
+	sum := sha256.Sum256(data)
+	return hex.EncodeToString(sum[:])
+}
@@ -0,0 +1,219 @@
+package wisent
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+)
+
+// BenchmarkResult holds the metrics collected during a benchmark run.
+// It is safe to read once the benchmark has completed.
+type BenchmarkResult struct {
+	// Name is the label set with WithBenchmarkName.
+	Name          string
+	TotalRequests int64
+	Errors        int64
+	MinLatency    time.Duration
+	MaxLatency    time.Duration
+	MeanLatency   time.Duration
+	// Duration is the wall-clock time of the run, excluding setup.
+	Duration time.Duration
+	// Latencies contains the latency of every request, in order of completion.
+	// With WithMetricsSamplingRate, only a sample of requests is included.
+	Latencies []time.Duration
+
+	mu      sync.Mutex
+	lastErr error
+	sorted  []time.Duration
+	started time.Time
+	// sampled decides whether the latency of a request is recorded.
+	sampled func() bool
+}
+
+func (r *BenchmarkResult) record(latency time.Duration, err error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.TotalRequests++
+	if r.sampled == nil || r.sampled() {
+		r.Latencies = append(r.Latencies, latency)
+	}
+	if err != nil {
+		r.Errors++
+		r.lastErr = err
+	}
+}
+
+func (r *BenchmarkResult) summarize() {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if !r.started.IsZero() {
+		r.Duration = time.Since(r.started)
+	}
+	if len(r.Latencies) == 0 {
+		return
+	}
+	var sum time.Duration
+	r.MinLatency, r.MaxLatency = r.Latencies[0], r.Latencies[0]
+	for _, latency := range r.Latencies {
+		sum += latency
+		r.MinLatency = min(r.MinLatency, latency)
+		r.MaxLatency = max(r.MaxLatency, latency)
+	}
+	r.MeanLatency = sum / time.Duration(len(r.Latencies))
+}
+
+// ErrInvalidPercentile is returned when a percentile outside of the [0, 100] range is requested.
+var ErrInvalidPercentile = errors.New("percentile must be between 0 and 100")
+
+// Percentile returns the p-th percentile of request latencies, using the nearest-rank method.
+// It returns 0 if no latencies were recorded.
+func (r *BenchmarkResult) Percentile(p float64) (time.Duration, error) {
+	if p < 0 || p > 100 {
+		return 0, ErrInvalidPercentile
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if len(r.Latencies) == 0 {
+		return 0, nil
+	}
+	if len(r.sorted) != len(r.Latencies) {
+		r.sorted = slices.Clone(r.Latencies)
+		slices.Sort(r.sorted)
+	}
+	return r.sorted[nearestRank(p, len(r.sorted))], nil
+}
+
+// Summary returns a human-readable summary of latency percentiles, suitable for t.Log.
+func (r *BenchmarkResult) Summary() string {
+	var sb strings.Builder
+	for _, p := range []float64{50, 95, 99, 99.9} {
+		latency, _ := r.Percentile(p)
+		fmt.Fprintf(&sb, "p%-5v %v\n", p, latency)
+	}
+	return sb.String()
+}
+
+// ErrorRate returns the fraction of requests that failed, between 0 and 1.
+func (r *BenchmarkResult) ErrorRate() float64 {
+	if r.TotalRequests == 0 {
+		return 0
+	}
+	return float64(r.Errors) / float64(r.TotalRequests)
+}
+
+// Throughput returns the number of requests per second over the duration of the run.
+func (r *BenchmarkResult) Throughput() float64 {
+	if r.Duration <= 0 {
+		return 0
+	}
+	return float64(r.TotalRequests) / r.Duration.Seconds()
+}
+
+// WriteReport writes a table with request counts, latencies and throughput of the run to w.
+// It is aligned for terminal output, and can be written to t.Log through a strings.Builder.
+func (r *BenchmarkResult) WriteReport(w io.Writer) error {
+	p50, _ := r.Percentile(50)
+	p95, _ := r.Percentile(95)
+	p99, _ := r.Percentile(99)
+
+	const row = "%-10s %-10s %-10s %-12s %-12s %-12s %-12s %-12s %-12s %s\n"
+	if r.Name != "" {
+		if _, err := fmt.Fprintf(w, "%s\n", r.Name); err != nil {
+			return err
+		}
+	}
+	if _, err := fmt.Fprintf(w, row, "requests", "errors", "error rate", "min", "mean", "max", "p50", "p95", "p99", "req/s"); err != nil {
+		return err
+	}
+	_, err := fmt.Fprintf(
+		w, row,
+		fmt.Sprint(r.TotalRequests), fmt.Sprint(r.Errors), fmt.Sprintf("%.2f%%", r.ErrorRate()*100),
+		r.MinLatency, r.MeanLatency, r.MaxLatency, p50, p95, p99, fmt.Sprintf("%.2f", r.Throughput()),
+	)
+	return err
+}
+
+// MarshalJSON encodes the summary of the run, with latencies in nanoseconds.
+// Individual latencies are not included.
+func (r *BenchmarkResult) MarshalJSON() ([]byte, error) {
+	p50, _ := r.Percentile(50)
+	p95, _ := r.Percentile(95)
+	p99, _ := r.Percentile(99)
+	return json.Marshal(struct {
+		Name          string        `json:"name,omitempty"`
+		TotalRequests int64         `json:"total_requests"`
+		Errors        int64         `json:"errors"`
+		ErrorRate     float64       `json:"error_rate"`
+		MinLatency    time.Duration `json:"min_latency_ns"`
+		MeanLatency   time.Duration `json:"mean_latency_ns"`
+		MaxLatency    time.Duration `json:"max_latency_ns"`
+		P50           time.Duration `json:"p50_ns"`
+		P95           time.Duration `json:"p95_ns"`
+		P99           time.Duration `json:"p99_ns"`
+		Duration      time.Duration `json:"duration_ns"`
+		Throughput    float64       `json:"throughput_rps"`
+	}{
+		Name:          r.Name,
+		TotalRequests: r.TotalRequests,
+		Errors:        r.Errors,
+		ErrorRate:     r.ErrorRate(),
+		MinLatency:    r.MinLatency,
+		MeanLatency:   r.MeanLatency,
+		MaxLatency:    r.MaxLatency,
+		P50:           p50,
+		P95:           p95,
+		P99:           p99,
+		Duration:      r.Duration,
+		Throughput:    r.Throughput(),
+	})
+}
+
+func (r *BenchmarkResult) err() error {
+	if r.lastErr != nil {
+		return fmt.Errorf("performing request: %w", r.lastErr)
+	}
+	return nil
+}
+
+// RegressionReport describes a latency metric that got worse compared to a baseline.
+type RegressionReport struct {
+	Metric    string
+	Baseline  time.Duration
+	Current   time.Duration
+	ChangePct float64
+}
+
+// Compare checks p50, p99 and mean latencies against a baseline result,
+// and reports every metric that regressed by more than thresholdPct percent.
+// Metrics with a zero baseline are not reported.
+func (r *BenchmarkResult) Compare(baseline *BenchmarkResult, thresholdPct float64) []RegressionReport {
+	metrics := []struct {
+		name string
+		get  func(*BenchmarkResult) time.Duration
+	}{
+		{"p50", func(r *BenchmarkResult) time.Duration { d, _ := r.Percentile(50); return d }},
+		{"p99", func(r *BenchmarkResult) time.Duration { d, _ := r.Percentile(99); return d }},
+		{"mean", func(r *BenchmarkResult) time.Duration { return r.MeanLatency }},
+	}
+
+	var reports []RegressionReport
+	for _, metric := range metrics {
+		base, current := metric.get(baseline), metric.get(r)
+		if base <= 0 {
+			continue
+		}
+		if change := float64(current-base) / float64(base) * 100; change > thresholdPct {
+			reports = append(reports, RegressionReport{Metric: metric.name, Baseline: base, Current: current, ChangePct: change})
+		}
+	}
+	return reports
+}
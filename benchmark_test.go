@@ -0,0 +1,126 @@
+package wisent
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestBenchmarkResultPercentile(t *testing.T) {
+	r := &BenchmarkResult{}
+	for i := 10; i > 0; i-- {
+		r.record(time.Duration(i)*time.Millisecond, nil)
+	}
+
+	tests := []struct {
+		p    float64
+		want time.Duration
+	}{
+		{p: 0, want: time.Millisecond},
+		{p: 50, want: 5 * time.Millisecond},
+		{p: 95, want: 10 * time.Millisecond},
+		{p: 100, want: 10 * time.Millisecond},
+	}
+	for _, tt := range tests {
+		got, err := r.Percentile(tt.p)
+		if err != nil {
+			t.Fatalf("Error getting percentile %v: %v", tt.p, err)
+		}
+		if got != tt.want {
+			t.Fatalf("Incorrect percentile %v, got: %v, want: %v", tt.p, got, tt.want)
+		}
+	}
+
+	if _, err := r.Percentile(101); !errors.Is(err, ErrInvalidPercentile) {
+		t.Fatalf("Incorrect error, got: %v, want: %v", err, ErrInvalidPercentile)
+	}
+}
+
+func TestBenchmarkResultCompare(t *testing.T) {
+	baseline, current := &BenchmarkResult{}, &BenchmarkResult{}
+	for i := 1; i <= 100; i++ {
+		baseline.record(10*time.Millisecond, nil)
+		latency := 10 * time.Millisecond
+		if i == 100 {
+			latency = time.Second
+		}
+		current.record(latency, nil)
+	}
+	baseline.summarize()
+	current.summarize()
+
+	reports := current.Compare(baseline, 10)
+
+	if len(reports) != 1 || reports[0].Metric != "mean" {
+		t.Fatalf("Incorrect regression reports, got: %+v, want: mean only", reports)
+	}
+}
+
+func TestBenchmarkResultReport(t *testing.T) {
+	r := &BenchmarkResult{Name: "users", Duration: 2 * time.Second}
+	for i := 0; i < 4; i++ {
+		var err error
+		if i == 0 {
+			err = errors.New("boom")
+		}
+		r.record(10*time.Millisecond, err)
+	}
+	r.summarize()
+
+	var sb strings.Builder
+	if err := r.WriteReport(&sb); err != nil {
+		t.Fatalf("Error writing report: %v", err)
+	}
+	for _, want := range []string{"users", "requests", "25.00%", "10ms", "2.00"} {
+		if !strings.Contains(sb.String(), want) {
+			t.Fatalf("Incorrect report, got: %q, want it to contain: %q", sb.String(), want)
+		}
+	}
+
+	data, err := json.Marshal(r)
+	if err != nil {
+		t.Fatalf("Error marshaling result: %v", err)
+	}
+	var got map[string]any
+	if err := json.Unmarshal(data, &got); err != nil {
+		t.Fatalf("Error unmarshaling result: %v", err)
+	}
+	if got["total_requests"] != 4.0 || got["error_rate"] != 0.25 || got["p99_ns"] != float64(10*time.Millisecond) {
+		t.Fatalf("Incorrect JSON result, got: %s", data)
+	}
+}
+
+func TestBenchmarkWarmupPerInstance(t *testing.T) {
+	var hits [2]atomic.Int32
+	instances := make([]*Wisent, len(hits))
+	for i := range hits {
+		server := httptest.NewServer(http.HandlerFunc(func(http.ResponseWriter, *http.Request) { hits[i].Add(1) }))
+		defer server.Close()
+		instances[i] = New(server.URL)
+	}
+
+	done := false
+	testing.Benchmark(func(b *testing.B) {
+		if done {
+			return
+		}
+		done = true
+		for _, w := range instances {
+			bm := Benchmark{RequestF: func() *http.Request { return w.NewRequest(http.MethodGet, "/", nil) }, WarmupIterations: 3}
+			// Only the first round warms up an external app, but every instance warms up its own.
+			w.warmup(b, bm)
+			w.warmup(b, bm)
+		}
+	})
+
+	for i := range hits {
+		if got := hits[i].Load(); got != 3 {
+			t.Fatalf("Incorrect warmup requests of instance %d, got: %d, want: 3", i, got)
+		}
+	}
+}
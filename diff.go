@@ -0,0 +1,132 @@
+package wisent
+
+import (
+	"fmt"
+	"strings"
+)
+
+// maxDiffCells limits the size of the table used to find the smallest diff, to about 16MB.
+const maxDiffCells = 1 << 22
+
+// unifiedDiff returns a line-based unified diff between two texts, with three lines of context.
+// It returns an empty string if the texts are equal. Lines that differ between the common prefix and suffix
+// of large texts are shown as a single change, instead of the smallest diff.
+func unifiedDiff(fromName, toName, from, to string) string {
+	if from == to {
+		return ""
+	}
+	a, b := splitLines(from), splitLines(to)
+
+	type edit struct {
+		op   byte
+		line string
+		i, j int
+	}
+	var edits []edit
+
+	// Only the lines between the common prefix and suffix need to be compared.
+	prefix := 0
+	for prefix < len(a) && prefix < len(b) && a[prefix] == b[prefix] {
+		prefix++
+	}
+	suffix := 0
+	for suffix < len(a)-prefix && suffix < len(b)-prefix && a[len(a)-1-suffix] == b[len(b)-1-suffix] {
+		suffix++
+	}
+	for k := 0; k < prefix; k++ {
+		edits = append(edits, edit{' ', a[k], k, k})
+	}
+	ma, mb := a[prefix:len(a)-suffix], b[prefix:len(b)-suffix]
+
+	if len(ma)*len(mb) > maxDiffCells {
+		// The table would take too much memory, so the changed lines are shown as removed and added as a whole.
+		for k, line := range ma {
+			edits = append(edits, edit{'-', line, prefix + k, prefix})
+		}
+		for k, line := range mb {
+			edits = append(edits, edit{'+', line, prefix + len(ma), prefix + k})
+		}
+	} else {
+		// lcs[i][j] holds the length of the longest common subsequence of ma[i:] and mb[j:].
+		lcs := make([][]int32, len(ma)+1)
+		for i := range lcs {
+			lcs[i] = make([]int32, len(mb)+1)
+		}
+		for i := len(ma) - 1; i >= 0; i-- {
+			for j := len(mb) - 1; j >= 0; j-- {
+				if ma[i] == mb[j] {
+					lcs[i][j] = lcs[i+1][j+1] + 1
+				} else {
+					lcs[i][j] = max(lcs[i+1][j], lcs[i][j+1])
+				}
+			}
+		}
+
+		i, j := 0, 0
+		for i < len(ma) || j < len(mb) {
+			switch {
+			case i < len(ma) && j < len(mb) && ma[i] == mb[j]:
+				edits = append(edits, edit{' ', ma[i], prefix + i, prefix + j})
+				i, j = i+1, j+1
+			case i < len(ma) && (j == len(mb) || lcs[i+1][j] >= lcs[i][j+1]):
+				edits = append(edits, edit{'-', ma[i], prefix + i, prefix + j})
+				i++
+			default:
+				edits = append(edits, edit{'+', mb[j], prefix + i, prefix + j})
+				j++
+			}
+		}
+	}
+	for k := suffix; k > 0; k-- {
+		edits = append(edits, edit{' ', a[len(a)-k], len(a) - k, len(b) - k})
+	}
+
+	const context = 3
+	var sb strings.Builder
+	fmt.Fprintf(&sb, "--- %s\n+++ %s\n", fromName, toName)
+	for start := 0; start < len(edits); {
+		if edits[start].op == ' ' {
+			start++
+			continue
+		}
+		// Extend the hunk until there are more than 2*context unchanged lines in a row.
+		end, unchanged := start, 0
+		for k := start; k < len(edits) && unchanged <= 2*context; k++ {
+			if edits[k].op == ' ' {
+				unchanged++
+			} else {
+				unchanged, end = 0, k
+			}
+		}
+		first, last := max(start-context, 0), min(end+context+1, len(edits))
+
+		var aLen, bLen int
+		for _, e := range edits[first:last] {
+			if e.op != '+' {
+				aLen++
+			}
+			if e.op != '-' {
+				bLen++
+			}
+		}
+		fmt.Fprintf(&sb, "@@ -%d,%d +%d,%d @@\n", edits[first].i+1, aLen, edits[first].j+1, bLen)
+		for _, e := range edits[first:last] {
+			sb.WriteByte(e.op)
+			sb.WriteString(e.line)
+			if !strings.HasSuffix(e.line, "\n") {
+				sb.WriteString("\n\\ No newline at end of file\n")
+			}
+		}
+		start = last
+	}
+	return sb.String()
+}
+
+// splitLines splits the text into lines, keeping the line endings.
+func splitLines(s string) []string {
+	lines := strings.SplitAfter(s, "\n")
+	if lines[len(lines)-1] == "" {
+		lines = lines[:len(lines)-1]
+	}
+	return lines
+}
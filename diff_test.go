@@ -0,0 +1,70 @@
+package wisent
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+)
+
+func TestUnifiedDiff(t *testing.T) {
+	from := "a\nb\nc\nd\ne\nf\ng\nh\ni\nj\n"
+	to := "a\nB\nc\nd\ne\nf\ng\nh\ni\nj\nk\n"
+
+	expected := `--- golden
++++ response
+@@ -1,5 +1,5 @@
+ a
+-b
++B
+ c
+ d
+ e
+@@ -8,3 +8,4 @@
+ h
+ i
+ j
++k
+`
+	if diff := unifiedDiff("golden", "response", from, to); diff != expected {
+		t.Fatalf("Incorrect diff, got:\n%s\nwant:\n%s", diff, expected)
+	}
+	if diff := unifiedDiff("golden", "response", from, from); diff != "" {
+		t.Fatalf("Incorrect diff of equal texts, got:\n%s", diff)
+	}
+}
+
+func TestUnifiedDiffLarge(t *testing.T) {
+	var from, to, other strings.Builder
+	for i := range 20000 {
+		fmt.Fprintf(&from, "line %d\n", i)
+		fmt.Fprintf(&other, "other %d\n", i)
+		if i == 10000 {
+			to.WriteString("changed\n")
+		} else {
+			fmt.Fprintf(&to, "line %d\n", i)
+		}
+	}
+
+	expected := `--- golden
++++ response
+@@ -9998,7 +9998,7 @@
+ line 9997
+ line 9998
+ line 9999
+-line 10000
++changed
+ line 10001
+ line 10002
+ line 10003
+`
+	if diff := unifiedDiff("golden", "response", from.String(), to.String()); diff != expected {
+		t.Fatalf("Incorrect diff, got:\n%s\nwant:\n%s", diff, expected)
+	}
+
+	// Texts without common lines are too large to compare, so they are shown as a single change.
+	diff := unifiedDiff("golden", "response", from.String(), other.String())
+	if !strings.HasPrefix(diff, "--- golden\n+++ response\n@@ -1,20000 +1,20000 @@\n-line 0\n") ||
+		strings.Count(diff, "\n-line ") != 20000 || strings.Count(diff, "\n+other ") != 20000 {
+		t.Fatalf("Incorrect diff of texts without common lines, got %d bytes:\n%.200s", len(diff), diff)
+	}
+}
@@ -0,0 +1,211 @@
+package wisent
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"os"
+	"runtime/debug"
+	"sort"
+	"sync"
+	"time"
+	"unicode/utf8"
+)
+
+// harLog collects request and response pairs in the HTTP Archive 1.2 format. It is safe for concurrent use.
+type harLog struct {
+	mu      sync.Mutex
+	entries []harEntry
+}
+
+type harEntry struct {
+	StartedDateTime time.Time   `json:"startedDateTime"`
+	Time            float64     `json:"time"`
+	Request         harRequest  `json:"request"`
+	Response        harResponse `json:"response"`
+	Cache           struct{}    `json:"cache"`
+	Timings         harTimings  `json:"timings"`
+	Comment         string      `json:"comment,omitempty"`
+}
+
+type harRequest struct {
+	Method      string         `json:"method"`
+	URL         string         `json:"url"`
+	HTTPVersion string         `json:"httpVersion"`
+	Cookies     []harNameValue `json:"cookies"`
+	Headers     []harNameValue `json:"headers"`
+	QueryString []harNameValue `json:"queryString"`
+	PostData    *harPostData   `json:"postData,omitempty"`
+	HeadersSize int            `json:"headersSize"`
+	BodySize    int            `json:"bodySize"`
+}
+
+type harPostData struct {
+	MimeType string `json:"mimeType"`
+	Text     string `json:"text"`
+}
+
+type harResponse struct {
+	Status      int            `json:"status"`
+	StatusText  string         `json:"statusText"`
+	HTTPVersion string         `json:"httpVersion"`
+	Cookies     []harNameValue `json:"cookies"`
+	Headers     []harNameValue `json:"headers"`
+	Content     harContent     `json:"content"`
+	RedirectURL string         `json:"redirectURL"`
+	HeadersSize int            `json:"headersSize"`
+	BodySize    int            `json:"bodySize"`
+}
+
+type harContent struct {
+	Size     int    `json:"size"`
+	MimeType string `json:"mimeType"`
+	Text     string `json:"text,omitempty"`
+	Encoding string `json:"encoding,omitempty"`
+}
+
+type harNameValue struct {
+	Name  string `json:"name"`
+	Value string `json:"value"`
+}
+
+type harTimings struct {
+	Send    float64 `json:"send"`
+	Wait    float64 `json:"wait"`
+	Receive float64 `json:"receive"`
+}
+
+// record adds an entry for the request and its outcome. The request body must already be buffered,
+// and the response body is buffered with readBody, so that it can still be read by the caller.
+// Failed requests are recorded with status 0 and the error as a comment, as in browser exports.
+func (l *harLog) record(started time.Time, req *http.Request, reqBody []byte, resp *http.Response, err error) {
+	elapsed := float64(time.Since(started).Microseconds()) / 1000
+	entry := harEntry{
+		StartedDateTime: started,
+		Time:            elapsed,
+		Request: harRequest{
+			Method:      req.Method,
+			URL:         req.URL.String(),
+			HTTPVersion: req.Proto,
+			Cookies:     harCookies(req.Cookies()),
+			Headers:     harHeaders(req.Header),
+			QueryString: harHeaders(req.URL.Query()),
+			HeadersSize: -1,
+			BodySize:    len(reqBody),
+		},
+		Response: harResponse{HeadersSize: -1, BodySize: -1, Cookies: []harNameValue{}, Headers: []harNameValue{}},
+		Timings:  harTimings{Send: 0, Wait: elapsed, Receive: 0},
+	}
+	if len(reqBody) > 0 {
+		entry.Request.PostData = &harPostData{MimeType: req.Header.Get("Content-Type"), Text: string(reqBody)}
+	}
+
+	if err != nil {
+		entry.Comment = err.Error()
+	} else {
+		body, readErr := readBody(resp)
+		if readErr != nil {
+			entry.Comment = readErr.Error()
+		}
+		entry.Response = harResponse{
+			Status:      resp.StatusCode,
+			StatusText:  http.StatusText(resp.StatusCode),
+			HTTPVersion: resp.Proto,
+			Cookies:     harCookies(resp.Cookies()),
+			Headers:     harHeaders(resp.Header),
+			Content:     harContent{Size: len(body), MimeType: resp.Header.Get("Content-Type")},
+			RedirectURL: resp.Header.Get("Location"),
+			HeadersSize: -1,
+			BodySize:    len(body),
+		}
+		if utf8.Valid(body) {
+			entry.Response.Content.Text = string(body)
+		} else {
+			entry.Response.Content.Text = base64.StdEncoding.EncodeToString(body)
+			entry.Response.Content.Encoding = "base64"
+		}
+	}
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.entries = append(l.entries, entry)
+}
+
+func harHeaders(headers map[string][]string) []harNameValue {
+	pairs := []harNameValue{}
+	for _, key := range sortedKeys(headers) {
+		for _, value := range headers[key] {
+			pairs = append(pairs, harNameValue{Name: key, Value: value})
+		}
+	}
+	return pairs
+}
+
+func harCookies(cookies []*http.Cookie) []harNameValue {
+	pairs := make([]harNameValue, 0, len(cookies))
+	for _, cookie := range cookies {
+		pairs = append(pairs, harNameValue{Name: cookie.Name, Value: cookie.Value})
+	}
+	return pairs
+}
+
+// WriteHAR writes all requests and responses captured so far to a HAR 1.2 file,
+// which can be imported e.g. by browser developer tools or Postman.
+// Capturing must be enabled with WithHARCapture.
+func (w *Wisent) WriteHAR(path string) error {
+	if w.harLog == nil {
+		return errors.New("HAR capture is not enabled, use WithHARCapture")
+	}
+
+	w.harLog.mu.Lock()
+	entries := append([]harEntry{}, w.harLog.entries...)
+	w.harLog.mu.Unlock()
+	sort.SliceStable(entries, func(i, j int) bool { return entries[i].StartedDateTime.Before(entries[j].StartedDateTime) })
+
+	var har struct {
+		Log struct {
+			Version string `json:"version"`
+			Creator struct {
+				Name    string `json:"name"`
+				Version string `json:"version"`
+			} `json:"creator"`
+			Entries []harEntry `json:"entries"`
+		} `json:"log"`
+	}
+	har.Log.Version = "1.2"
+	har.Log.Creator.Name = "wisent"
+	har.Log.Creator.Version = moduleVersion()
+	har.Log.Entries = entries
+
+	data, err := json.MarshalIndent(har, "", "  ")
+	if err != nil {
+		return fmt.Errorf("encoding HAR: %w", err)
+	}
+	if err := os.WriteFile(path, data, 0o644); err != nil {
+		return fmt.Errorf("writing HAR: %w", err)
+	}
+	return nil
+}
+
+// moduleVersion returns the version of wisent the binary was built with, or an empty string if it is not known.
+func moduleVersion() string {
+	info, ok := debug.ReadBuildInfo()
+	if !ok {
+		return ""
+	}
+	const path = "github.com/ttyobiwan/wisent"
+	if info.Main.Path == path {
+		return info.Main.Version
+	}
+	for _, dep := range info.Deps {
+		if dep.Path == path {
+			if dep.Replace != nil {
+				return dep.Replace.Version
+			}
+			return dep.Version
+		}
+	}
+	return ""
+}
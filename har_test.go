@@ -0,0 +1,92 @@
+package wisent_test
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/ttyobiwan/wisent"
+)
+
+func TestWriteHAR(t *testing.T) {
+	mux := http.NewServeMux()
+	mux.HandleFunc("POST /items", func(w http.ResponseWriter, _ *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusCreated)
+		w.Write([]byte(`{"id":"42"}`))
+	})
+	mux.HandleFunc("GET /image", func(w http.ResponseWriter, _ *http.Request) {
+		w.Write([]byte{0xff, 0xd8, 0xff})
+	})
+	server := httptest.NewServer(mux)
+	defer server.Close()
+
+	w := wisent.New(server.URL, wisent.WithHARCapture())
+	w.Test(t, []wisent.Test{
+		{
+			Name:           "create",
+			Request:        w.NewJSONRequest(http.MethodPost, "/items?dry=false", map[string]string{"name": "a"}),
+			AssertResponse: func(resp *http.Response, err error) { w.AssertResponseBody(t, `{"id":"42"}`, resp) },
+		},
+		{
+			Name:           "image",
+			Request:        w.NewRequest(http.MethodGet, "/image", nil),
+			AssertResponse: func(resp *http.Response, err error) { w.AssertResponseBody(t, "\xff\xd8\xff", resp) },
+		},
+	})
+
+	path := filepath.Join(t.TempDir(), "run.har")
+	if err := w.WriteHAR(path); err != nil {
+		t.Fatalf("Error writing HAR: %v", err)
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatalf("Error reading HAR: %v", err)
+	}
+	var har struct {
+		Log struct {
+			Version string
+			Entries []struct {
+				Request struct {
+					Method      string
+					URL         string
+					QueryString []struct{ Name, Value string }
+					PostData    struct{ MimeType, Text string }
+				}
+				Response struct {
+					Status  int
+					Content struct {
+						Size                     int
+						MimeType, Text, Encoding string
+					}
+				}
+			}
+		}
+	}
+	if err := json.Unmarshal(data, &har); err != nil {
+		t.Fatalf("Error decoding HAR: %v", err)
+	}
+
+	if har.Log.Version != "1.2" || len(har.Log.Entries) != 2 {
+		t.Fatalf("Incorrect HAR log, got version %q with %d entries, want: version \"1.2\" with 2 entries", har.Log.Version, len(har.Log.Entries))
+	}
+	create, image := har.Log.Entries[0], har.Log.Entries[1]
+	if create.Request.Method != http.MethodPost || create.Request.URL != server.URL+"/items?dry=false" {
+		t.Fatalf("Incorrect request, got: %v %v", create.Request.Method, create.Request.URL)
+	}
+	if q := create.Request.QueryString; len(q) != 1 || q[0].Name != "dry" || q[0].Value != "false" {
+		t.Fatalf("Incorrect query string, got: %v", q)
+	}
+	if create.Request.PostData.MimeType != "application/json" || create.Request.PostData.Text != `{"name":"a"}` {
+		t.Fatalf("Incorrect post data, got: %+v", create.Request.PostData)
+	}
+	if create.Response.Status != http.StatusCreated || create.Response.Content.Text != `{"id":"42"}` || create.Response.Content.Encoding != "" {
+		t.Fatalf("Incorrect response, got: %+v", create.Response)
+	}
+	if image.Response.Content.Text != "/9j/" || image.Response.Content.Encoding != "base64" || image.Response.Content.Size != 3 {
+		t.Fatalf("Incorrect binary content, got: %+v", image.Response.Content)
+	}
+}
@@ -0,0 +1,359 @@
+package wisent
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"math/big"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"testing"
+)
+
+// readBody reads the whole response body and replaces it with an in-memory copy,
+// so that the body can be read again by subsequent assertions.
+func readBody(resp *http.Response) ([]byte, error) {
+	body, err := io.ReadAll(resp.Body)
+	resp.Body.Close()
+	resp.Body = io.NopCloser(bytes.NewReader(body))
+	return body, err
+}
+
+// unmarshalJSON decodes the data into generic values.
+// Numbers are decoded as json.Number to avoid losing precision.
+func unmarshalJSON(data []byte) (any, error) {
+	decoder := json.NewDecoder(bytes.NewReader(data))
+	decoder.UseNumber()
+	var v any
+	if err := decoder.Decode(&v); err != nil {
+		return nil, err
+	}
+	if decoder.More() {
+		return nil, fmt.Errorf("unexpected data after top-level value")
+	}
+	return v, nil
+}
+
+// readJSON reads and decodes the response body, failing the test on error.
+func readJSON(tb testing.TB, resp *http.Response) any {
+	body, err := readBody(resp)
+	if err != nil {
+		tb.Fatalf("Error reading response body: %v", err)
+	}
+	v, err := unmarshalJSON(body)
+	if err != nil {
+		tb.Fatalf("Error decoding response body: %v\nBody: %s", err, body)
+	}
+	return v
+}
+
+// readJSONString reads and decodes the response body, and returns the string at the given field or path,
+// failing the test if it does not exist or is not a string.
+func readJSONString(tb testing.TB, resp *http.Response, field string) string {
+	doc := readJSON(tb, resp)
+	v, err := lookupJSONPath(doc, field)
+	if err != nil {
+		tb.Fatalf("Error evaluating path %q: %v\nDocument: %s", field, err, formatJSON(doc))
+	}
+	str, ok := v.(string)
+	if !ok {
+		tb.Fatalf("Value of %q is not a string: %s", field, formatJSON(v))
+	}
+	return str
+}
+
+// readJSONArray reads and decodes the response body, failing the test if it is not a JSON array.
+func readJSONArray(tb testing.TB, resp *http.Response) []any {
+	v := readJSON(tb, resp)
+	arr, ok := v.([]any)
+	if !ok {
+		tb.Fatalf("Response body is not a JSON array: %v", v)
+	}
+	return arr
+}
+
+// fieldValues extracts the given field from every element of the array,
+// failing the test if any element is not an object or misses the field.
+func fieldValues(tb testing.TB, arr []any, field string) []any {
+	values := make([]any, 0, len(arr))
+	for i, elem := range arr {
+		obj, ok := elem.(map[string]any)
+		if !ok {
+			tb.Fatalf("Element at index %d is not a JSON object: %v", i, elem)
+		}
+		v, ok := obj[field]
+		if !ok {
+			tb.Fatalf("Element at index %d has no field %q: %v", i, field, elem)
+		}
+		values = append(values, v)
+	}
+	return values
+}
+
+// compareJSONValues compares two decoded JSON values of the same kind.
+// Numbers are compared numerically, strings lexicographically.
+func compareJSONValues(a, b any) (int, error) {
+	switch a := a.(type) {
+	case json.Number:
+		b, ok := b.(json.Number)
+		if !ok {
+			return 0, fmt.Errorf("cannot compare %v with %v", a, b)
+		}
+		af, err := a.Float64()
+		if err != nil {
+			return 0, err
+		}
+		bf, err := b.Float64()
+		if err != nil {
+			return 0, err
+		}
+		switch {
+		case af < bf:
+			return -1, nil
+		case af > bf:
+			return 1, nil
+		}
+		return 0, nil
+	case string:
+		b, ok := b.(string)
+		if !ok {
+			return 0, fmt.Errorf("cannot compare %v with %v", a, b)
+		}
+		return strings.Compare(a, b), nil
+	}
+	return 0, fmt.Errorf("cannot compare values of type %T", a)
+}
+
+// diffJSON compares two decoded JSON values and describes every difference found, prefixed by its path.
+func diffJSON(path string, expected, actual any) []string {
+	switch expected := expected.(type) {
+	case map[string]any:
+		actual, ok := actual.(map[string]any)
+		if !ok {
+			return []string{fmt.Sprintf("%s: expected object, got %s", path, formatJSON(actual))}
+		}
+		var diffs []string
+		for _, key := range sortedKeys(expected) {
+			actualValue, ok := actual[key]
+			if !ok {
+				diffs = append(diffs, fmt.Sprintf("%s.%s: missing, expected %s", path, key, formatJSON(expected[key])))
+				continue
+			}
+			diffs = append(diffs, diffJSON(path+"."+key, expected[key], actualValue)...)
+		}
+		for _, key := range sortedKeys(actual) {
+			if _, ok := expected[key]; !ok {
+				diffs = append(diffs, fmt.Sprintf("%s.%s: unexpected, got %s", path, key, formatJSON(actual[key])))
+			}
+		}
+		return diffs
+	case []any:
+		actual, ok := actual.([]any)
+		if !ok {
+			return []string{fmt.Sprintf("%s: expected array, got %s", path, formatJSON(actual))}
+		}
+		if len(expected) != len(actual) {
+			return []string{fmt.Sprintf("%s: expected %d elements, got %d", path, len(expected), len(actual))}
+		}
+		var diffs []string
+		for i := range expected {
+			diffs = append(diffs, diffJSON(fmt.Sprintf("%s[%d]", path, i), expected[i], actual[i])...)
+		}
+		return diffs
+	case json.Number:
+		if actual, ok := actual.(json.Number); ok && equalNumbers(expected, actual) {
+			return nil
+		}
+	default:
+		if expected == actual {
+			return nil
+		}
+	}
+	return []string{fmt.Sprintf("%s: expected %s, got %s", path, formatJSON(expected), formatJSON(actual))}
+}
+
+// equalNumbers compares two JSON numbers without losing precision, so that e.g. 1 and 1.0 are equal.
+func equalNumbers(a, b json.Number) bool {
+	af, _, errA := big.ParseFloat(string(a), 10, 256, big.ToNearestEven)
+	bf, _, errB := big.ParseFloat(string(b), 10, 256, big.ToNearestEven)
+	if errA != nil || errB != nil {
+		return a == b
+	}
+	return af.Cmp(bf) == 0
+}
+
+// formatJSON encodes a decoded JSON value back to its compact form for error messages.
+func formatJSON(v any) string {
+	data, err := json.Marshal(v)
+	if err != nil {
+		return fmt.Sprint(v)
+	}
+	return string(data)
+}
+
+func sortedKeys[V any](m map[string]V) []string {
+	keys := make([]string, 0, len(m))
+	for key := range m {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+// jsonValueString returns strings as is and other values in their JSON form.
+func jsonValueString(v any) string {
+	if s, ok := v.(string); ok {
+		return s
+	}
+	return formatJSON(v)
+}
+
+// normalizeJSON converts any value to its decoded JSON form, so it can be compared with decoded response bodies.
+func normalizeJSON(v any) (any, error) {
+	data, err := json.Marshal(v)
+	if err != nil {
+		return nil, err
+	}
+	return unmarshalJSON(data)
+}
+
+// numbers converts decoded JSON values to floats, failing the test if any value is not a number.
+func numbers(tb testing.TB, values []any) []float64 {
+	result := make([]float64, 0, len(values))
+	for i, v := range values {
+		n, ok := v.(json.Number)
+		if !ok {
+			tb.Fatalf("Element at index %d is not a number: %s", i, formatJSON(v))
+		}
+		f, err := n.Float64()
+		if err != nil {
+			tb.Fatalf("Error parsing number at index %d: %v", i, err)
+		}
+		result = append(result, f)
+	}
+	return result
+}
+
+// errPathNotFound is returned when a JSON path does not resolve to a value.
+var errPathNotFound = errors.New("path not found")
+
+// lookupJSONPath evaluates a simple JSONPath expression against a decoded JSON value.
+// Supported are child access with dots or brackets and array indexes,
+// e.g. "$.user.name", "$.items[0].id" or "$['user']['first name']". The leading "$" is optional.
+// Paths starting with "/" are evaluated as JSON Pointers instead, e.g. "/items/0/id".
+func lookupJSONPath(v any, path string) (any, error) {
+	if strings.HasPrefix(path, "/") {
+		return lookupJSONPointer(v, path)
+	}
+	rest := strings.TrimPrefix(path, "$")
+	for rest != "" {
+		switch {
+		case rest[0] == '.':
+			rest = rest[1:]
+			end := strings.IndexAny(rest, ".[")
+			if end == -1 {
+				end = len(rest)
+			}
+			key := rest[:end]
+			rest = rest[end:]
+			if key == "" {
+				return nil, fmt.Errorf("empty key in path %q", path)
+			}
+			obj, ok := v.(map[string]any)
+			if !ok {
+				return nil, fmt.Errorf("%w: %q is not an object", errPathNotFound, strings.TrimSuffix(path, rest))
+			}
+			if v, ok = obj[key]; !ok {
+				return nil, fmt.Errorf("%w: %q", errPathNotFound, strings.TrimSuffix(path, rest))
+			}
+		case rest[0] == '[':
+			end := strings.IndexByte(rest, ']')
+			if end == -1 {
+				return nil, fmt.Errorf("unclosed bracket in path %q", path)
+			}
+			selector := rest[1:end]
+			rest = rest[end+1:]
+			if len(selector) >= 2 && (selector[0] == '\'' || selector[0] == '"') && selector[len(selector)-1] == selector[0] {
+				obj, ok := v.(map[string]any)
+				if !ok {
+					return nil, fmt.Errorf("%w: %q is not an object", errPathNotFound, strings.TrimSuffix(path, rest))
+				}
+				if v, ok = obj[selector[1:len(selector)-1]]; !ok {
+					return nil, fmt.Errorf("%w: %q", errPathNotFound, strings.TrimSuffix(path, rest))
+				}
+				continue
+			}
+			index, err := strconv.Atoi(selector)
+			if err != nil {
+				return nil, fmt.Errorf("invalid index %q in path %q", selector, path)
+			}
+			arr, ok := v.([]any)
+			if !ok {
+				return nil, fmt.Errorf("%w: %q is not an array", errPathNotFound, strings.TrimSuffix(path, rest))
+			}
+			if index < 0 || index >= len(arr) {
+				return nil, fmt.Errorf("%w: %q is out of range", errPathNotFound, strings.TrimSuffix(path, rest))
+			}
+			v = arr[index]
+		default:
+			if rest != strings.TrimPrefix(path, "$") {
+				return nil, fmt.Errorf("unexpected %q in path %q", rest, path)
+			}
+			rest = "." + rest
+		}
+	}
+	return v, nil
+}
+
+// lookupJSONPointer evaluates a JSON Pointer (RFC 6901) against a decoded JSON value.
+func lookupJSONPointer(v any, pointer string) (any, error) {
+	tokens := strings.Split(pointer, "/")[1:]
+	for i, token := range tokens {
+		token = strings.ReplaceAll(strings.ReplaceAll(token, "~1", "/"), "~0", "~")
+		current := "/" + strings.Join(tokens[:i+1], "/")
+		switch node := v.(type) {
+		case map[string]any:
+			var ok bool
+			if v, ok = node[token]; !ok {
+				return nil, fmt.Errorf("%w: %q", errPathNotFound, current)
+			}
+		case []any:
+			index, err := strconv.Atoi(token)
+			if err != nil || index < 0 || index >= len(node) {
+				return nil, fmt.Errorf("%w: %q is out of range", errPathNotFound, current)
+			}
+			v = node[index]
+		default:
+			return nil, fmt.Errorf("%w: %q has no parent object or array", errPathNotFound, current)
+		}
+	}
+	return v, nil
+}
+
+// mergePatch applies a JSON Merge Patch to the target, as defined by RFC 7396.
+// The target is not modified.
+func mergePatch(target, patch any) any {
+	patchObj, ok := patch.(map[string]any)
+	if !ok {
+		return patch
+	}
+	targetObj, ok := target.(map[string]any)
+	result := make(map[string]any, len(targetObj))
+	if ok {
+		for key, value := range targetObj {
+			result[key] = value
+		}
+	}
+	for key, value := range patchObj {
+		if value == nil {
+			delete(result, key)
+		} else {
+			result[key] = mergePatch(result[key], value)
+		}
+	}
+	return result
+}
@@ -0,0 +1,137 @@
+package wisent
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+)
+
+func TestDiffJSON(t *testing.T) {
+	tests := []struct {
+		name     string
+		expected string
+		actual   string
+		diffs    []string
+	}{
+		{
+			name:     "equal with different order and whitespace",
+			expected: `{"a": 1, "b": [1, 2]}`,
+			actual:   `{"b":[1,2],"a":1}`,
+		},
+		{
+			name:     "equal numbers with different notation",
+			expected: `{"a": 1.0}`,
+			actual:   `{"a": 1}`,
+		},
+		{
+			name:     "null is not missing",
+			expected: `{"a": null}`,
+			actual:   `{"b": null}`,
+			diffs:    []string{`$.a: missing, expected null`, `$.b: unexpected, got null`},
+		},
+		{
+			name:     "array order matters",
+			expected: `{"items": [{"id": 1}, {"id": 2}]}`,
+			actual:   `{"items": [{"id": 2}, {"id": 1}]}`,
+			diffs:    []string{`$.items[0].id: expected 1, got 2`, `$.items[1].id: expected 2, got 1`},
+		},
+		{
+			name:     "large numbers keep precision",
+			expected: `12345678901234567890`,
+			actual:   `12345678901234567891`,
+			diffs:    []string{`$: expected 12345678901234567890, got 12345678901234567891`},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			expected, err := unmarshalJSON([]byte(tt.expected))
+			if err != nil {
+				t.Fatal(err)
+			}
+			actual, err := unmarshalJSON([]byte(tt.actual))
+			if err != nil {
+				t.Fatal(err)
+			}
+			if diffs := diffJSON("$", expected, actual); !reflect.DeepEqual(diffs, tt.diffs) {
+				t.Fatalf("Incorrect diffs, got: %q, want: %q", diffs, tt.diffs)
+			}
+		})
+	}
+}
+
+func TestLookupJSONPath(t *testing.T) {
+	doc, err := unmarshalJSON([]byte(`{"user": {"name": "Jan", "first name": "Jan"}, "items": [{"id": 1}, {"id": 2}]}`))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		path     string
+		expected string
+		notFound bool
+	}{
+		{path: "$.user.name", expected: "Jan"},
+		{path: "user.name", expected: "Jan"},
+		{path: "$['user']['first name']", expected: "Jan"},
+		{path: "$.items[1].id", expected: "2"},
+		{path: "$.items", expected: `[{"id":1},{"id":2}]`},
+		{path: "/items/1/id", expected: "2"},
+		{path: "/user/first name", expected: "Jan"},
+		{path: "/items/2", notFound: true},
+		{path: "$.user.age", notFound: true},
+		{path: "$.items[2]", notFound: true},
+		{path: "$.user[0]", notFound: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.path, func(t *testing.T) {
+			v, err := lookupJSONPath(doc, tt.path)
+			if tt.notFound {
+				if !errors.Is(err, errPathNotFound) {
+					t.Fatalf("Incorrect error, got: %v, want: %v", err, errPathNotFound)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Error looking up path: %v", err)
+			}
+			if actual := jsonValueString(v); actual != tt.expected {
+				t.Fatalf("Incorrect value, got: %v, want: %v", actual, tt.expected)
+			}
+		})
+	}
+}
+
+func TestMergePatch(t *testing.T) {
+	// Examples from Appendix A of RFC 7396.
+	tests := []struct {
+		original string
+		patch    string
+		expected string
+	}{
+		{original: `{"a":"b"}`, patch: `{"a":"c"}`, expected: `{"a":"c"}`},
+		{original: `{"a":"b"}`, patch: `{"b":"c"}`, expected: `{"a":"b","b":"c"}`},
+		{original: `{"a":"b"}`, patch: `{"a":null}`, expected: `{}`},
+		{original: `{"a":"b","b":"c"}`, patch: `{"a":null}`, expected: `{"b":"c"}`},
+		{original: `{"a":["b"]}`, patch: `{"a":"c"}`, expected: `{"a":"c"}`},
+		{original: `{"a":"c"}`, patch: `{"a":["b"]}`, expected: `{"a":["b"]}`},
+		{original: `{"a":{"b":"c"}}`, patch: `{"a":{"b":"d","c":null}}`, expected: `{"a":{"b":"d"}}`},
+		{original: `{"a":[{"b":"c"}]}`, patch: `{"a":[1]}`, expected: `{"a":[1]}`},
+		{original: `["a","b"]`, patch: `["c","d"]`, expected: `["c","d"]`},
+		{original: `{"a":"b"}`, patch: `["c"]`, expected: `["c"]`},
+		{original: `{"a":"foo"}`, patch: `null`, expected: `null`},
+		{original: `{"e":null}`, patch: `{"a":1}`, expected: `{"e":null,"a":1}`},
+		{original: `[1,2]`, patch: `{"a":"b","c":null}`, expected: `{"a":"b"}`},
+		{original: `{}`, patch: `{"a":{"bb":{"ccc":null}}}`, expected: `{"a":{"bb":{}}}`},
+	}
+	for _, tt := range tests {
+		t.Run(tt.original+" "+tt.patch, func(t *testing.T) {
+			original, _ := unmarshalJSON([]byte(tt.original))
+			patch, _ := unmarshalJSON([]byte(tt.patch))
+			expected, _ := unmarshalJSON([]byte(tt.expected))
+			actual := mergePatch(original, patch)
+			if diffs := diffJSON("$", expected, actual); len(diffs) > 0 {
+				t.Fatalf("Incorrect result, got: %s, want: %s", formatJSON(actual), tt.expected)
+			}
+		})
+	}
+}
@@ -0,0 +1,85 @@
+package wisent
+
+import (
+	"io"
+	"math"
+	"slices"
+	"sync"
+)
+
+// BodySizeStats holds statistics of response body sizes, in bytes.
+// The exported fields are populated at the end of every test or benchmark run.
+// In benchmarks, only requests made after the timer is reset are counted, once per round of b.N.
+type BodySizeStats struct {
+	Min  int64
+	Max  int64
+	Mean int64
+	P95  int64
+
+	mu      sync.Mutex
+	samples []int64
+}
+
+func (s *BodySizeStats) record(size int64) {
+	s.mu.Lock()
+	s.samples = append(s.samples, size)
+	s.mu.Unlock()
+}
+
+// reset discards the samples and statistics collected so far.
+func (s *BodySizeStats) reset() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.Min, s.Max, s.Mean, s.P95 = 0, 0, 0, 0
+	s.samples = nil
+}
+
+func (s *BodySizeStats) summarize() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if len(s.samples) == 0 {
+		return
+	}
+	sorted := slices.Clone(s.samples)
+	slices.Sort(sorted)
+
+	var sum int64
+	for _, size := range sorted {
+		sum += size
+	}
+	s.Min = sorted[0]
+	s.Max = sorted[len(sorted)-1]
+	s.Mean = sum / int64(len(sorted))
+	s.P95 = sorted[nearestRank(95, len(sorted))]
+}
+
+// nearestRank returns the index of the p-th percentile in a sorted slice of length n.
+func nearestRank(p float64, n int) int {
+	rank := int(math.Ceil(p / 100 * float64(n)))
+	return min(max(rank-1, 0), n-1)
+}
+
+// countingBody counts the bytes of a response body and records the total when closed.
+// Unread bytes are drained on close, so the whole body is always accounted for.
+type countingBody struct {
+	io.ReadCloser
+	record func(int64)
+	n      int64
+	closed bool
+}
+
+func (b *countingBody) Read(p []byte) (int, error) {
+	n, err := b.ReadCloser.Read(p)
+	b.n += int64(n)
+	return n, err
+}
+
+func (b *countingBody) Close() error {
+	if !b.closed {
+		b.closed = true
+		n, _ := io.Copy(io.Discard, b.ReadCloser)
+		b.record(b.n + n)
+	}
+	return b.ReadCloser.Close()
+}
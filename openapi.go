@@ -0,0 +1,310 @@
+package wisent
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"mime"
+	"net/http"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"strings"
+	"testing"
+)
+
+// openAPISpec is a decoded OpenAPI 3.x document, used to validate response bodies against declared schemas.
+// Only the subset of JSON Schema commonly used in specs is supported: local $ref, type, nullable, enum,
+// properties, required, additionalProperties, items, allOf, anyOf and oneOf.
+type openAPISpec struct {
+	doc any
+}
+
+// loadOpenAPISpec reads and decodes a JSON OpenAPI spec.
+func loadOpenAPISpec(path string) (*openAPISpec, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	if ext := strings.ToLower(filepath.Ext(path)); ext == ".yaml" || ext == ".yml" {
+		return nil, errors.New("YAML specs are not supported, convert the spec to JSON first")
+	}
+	doc, err := unmarshalJSON(data)
+	if err != nil {
+		return nil, fmt.Errorf("decoding spec: %w", err)
+	}
+	if root, ok := doc.(map[string]any); !ok {
+		return nil, errors.New("spec is not a JSON object")
+	} else if _, ok := root["paths"].(map[string]any); !ok {
+		return nil, errors.New("spec has no paths")
+	}
+	return &openAPISpec{doc: doc}, nil
+}
+
+// responseSchema finds the schema of a response body, by the most specific path template matching the request path,
+// the status code (exact, range like "2XX" or "default") and the media type.
+// A nil schema without an error means that the response has no declared content.
+func (s *openAPISpec) responseSchema(method, path string, status int, contentType string) (any, error) {
+	paths := s.doc.(map[string]any)["paths"].(map[string]any)
+	item, ok := paths[path].(map[string]any)
+	if !ok {
+		// Like routers, prefer templates with more literal segments, e.g. "/users/me" over "/users/{id}".
+		best := -1
+		for _, template := range sortedKeys(paths) {
+			if literals, ok := matchPathTemplate(template, path); ok && literals > best {
+				item, _ = paths[template].(map[string]any)
+				best = literals
+			}
+		}
+	}
+	if item == nil {
+		return nil, fmt.Errorf("path %q is not in the spec", path)
+	}
+
+	operation, ok := item[strings.ToLower(method)].(map[string]any)
+	if !ok {
+		return nil, fmt.Errorf("operation %s %s is not in the spec", method, path)
+	}
+	responses, _ := operation["responses"].(map[string]any)
+	code := strconv.Itoa(status)
+	var response any
+	for _, key := range []string{code, code[:1] + "XX", code[:1] + "xx", "default"} {
+		if response, ok = responses[key]; ok {
+			break
+		}
+	}
+	if !ok {
+		return nil, fmt.Errorf("status code %d of %s %s is not in the spec", status, method, path)
+	}
+	response, err := s.resolve(response)
+	if err != nil {
+		return nil, err
+	}
+
+	obj, _ := response.(map[string]any)
+	content, _ := obj["content"].(map[string]any)
+	if len(content) == 0 {
+		return nil, nil
+	}
+	mediaType, _, _ := mime.ParseMediaType(contentType)
+	candidates := []string{mediaType, "*/*"}
+	if typ, _, ok := strings.Cut(mediaType, "/"); ok {
+		candidates = []string{mediaType, typ + "/*", "*/*"}
+	}
+	for _, candidate := range candidates {
+		if media, ok := content[candidate].(map[string]any); ok {
+			return media["schema"], nil
+		}
+	}
+	return nil, fmt.Errorf("content type %q of %s %s is not in the spec, want one of: %v", mediaType, method, path, sortedKeys(content))
+}
+
+// matchPathTemplate checks if a path matches a template like "/users/{id}",
+// and returns the number of literal segments in the template.
+func matchPathTemplate(template, path string) (int, bool) {
+	templateParts, pathParts := strings.Split(template, "/"), strings.Split(path, "/")
+	if len(templateParts) != len(pathParts) {
+		return 0, false
+	}
+	literals := 0
+	for i, part := range templateParts {
+		if strings.HasPrefix(part, "{") && strings.HasSuffix(part, "}") {
+			if pathParts[i] == "" {
+				return 0, false
+			}
+		} else if part != pathParts[i] {
+			return 0, false
+		} else {
+			literals++
+		}
+	}
+	return literals, true
+}
+
+// resolve follows local references like "#/components/schemas/User".
+func (s *openAPISpec) resolve(v any) (any, error) {
+	for range 32 {
+		obj, ok := v.(map[string]any)
+		if !ok {
+			return v, nil
+		}
+		ref, ok := obj["$ref"].(string)
+		if !ok {
+			return v, nil
+		}
+		if !strings.HasPrefix(ref, "#/") {
+			return nil, fmt.Errorf("unsupported reference %q", ref)
+		}
+		var err error
+		if v, err = lookupJSONPointer(s.doc, ref[1:]); err != nil {
+			return nil, fmt.Errorf("resolving reference %q: %w", ref, err)
+		}
+	}
+	return nil, errors.New("too many nested references")
+}
+
+var integerRegexp = regexp.MustCompile(`^-?\d+$`)
+
+// validate checks the decoded JSON value against the schema, and returns every violation prefixed with its path.
+func (s *openAPISpec) validate(path string, schema, v any) []string {
+	schema, err := s.resolve(schema)
+	if err != nil {
+		return []string{fmt.Sprintf("%s: %v", path, err)}
+	}
+	obj, ok := schema.(map[string]any)
+	if !ok {
+		return nil
+	}
+
+	if v == nil && obj["nullable"] == true {
+		return nil
+	}
+	if types := schemaTypes(obj["type"]); len(types) > 0 && !matchesType(types, v) {
+		return []string{fmt.Sprintf("%s: expected type %s, got %s", path, strings.Join(types, " or "), formatJSON(v))}
+	}
+	if enum, ok := obj["enum"].([]any); ok {
+		found := false
+		for _, value := range enum {
+			if len(diffJSON("", value, v)) == 0 {
+				found = true
+				break
+			}
+		}
+		if !found {
+			return []string{fmt.Sprintf("%s: expected one of %s, got %s", path, formatJSON(enum), formatJSON(v))}
+		}
+	}
+
+	var violations []string
+	if all, ok := obj["allOf"].([]any); ok {
+		for _, sub := range all {
+			violations = append(violations, s.validate(path, sub, v)...)
+		}
+	}
+	for _, keyword := range []string{"anyOf", "oneOf"} {
+		subs, ok := obj[keyword].([]any)
+		if !ok {
+			continue
+		}
+		matched := 0
+		for _, sub := range subs {
+			if len(s.validate(path, sub, v)) == 0 {
+				matched++
+			}
+		}
+		if matched == 0 || keyword == "oneOf" && matched > 1 {
+			violations = append(violations, fmt.Sprintf("%s: %d schemas of %s match %s", path, matched, keyword, formatJSON(v)))
+		}
+	}
+
+	switch v := v.(type) {
+	case map[string]any:
+		required, _ := obj["required"].([]any)
+		for _, field := range required {
+			if name, ok := field.(string); ok {
+				if _, ok := v[name]; !ok {
+					violations = append(violations, fmt.Sprintf("%s: missing required field %q", path, name))
+				}
+			}
+		}
+		properties, _ := obj["properties"].(map[string]any)
+		for _, key := range sortedKeys(v) {
+			if property, ok := properties[key]; ok {
+				violations = append(violations, s.validate(path+"."+key, property, v[key])...)
+				continue
+			}
+			switch additional := obj["additionalProperties"].(type) {
+			case bool:
+				if !additional {
+					violations = append(violations, fmt.Sprintf("%s: unexpected field %q", path, key))
+				}
+			case map[string]any:
+				violations = append(violations, s.validate(path+"."+key, additional, v[key])...)
+			}
+		}
+	case []any:
+		if items, ok := obj["items"]; ok {
+			for i, elem := range v {
+				violations = append(violations, s.validate(fmt.Sprintf("%s[%d]", path, i), items, elem)...)
+			}
+		}
+	}
+	return violations
+}
+
+// schemaTypes returns the allowed types of a schema, given either as a string or, in OpenAPI 3.1, as an array.
+func schemaTypes(v any) []string {
+	switch v := v.(type) {
+	case string:
+		return []string{v}
+	case []any:
+		types := make([]string, 0, len(v))
+		for _, typ := range v {
+			if typ, ok := typ.(string); ok {
+				types = append(types, typ)
+			}
+		}
+		return types
+	}
+	return nil
+}
+
+// matchesType checks if the decoded JSON value is of any of the JSON Schema types.
+func matchesType(types []string, v any) bool {
+	for _, typ := range types {
+		switch n, _ := v.(json.Number); typ {
+		case "object":
+			if _, ok := v.(map[string]any); ok {
+				return true
+			}
+		case "array":
+			if _, ok := v.([]any); ok {
+				return true
+			}
+		case "string":
+			if _, ok := v.(string); ok {
+				return true
+			}
+		case "number":
+			if _, ok := v.(json.Number); ok {
+				return true
+			}
+		case "integer":
+			if integerRegexp.MatchString(string(n)) {
+				return true
+			}
+		case "boolean":
+			if _, ok := v.(bool); ok {
+				return true
+			}
+		case "null":
+			if v == nil {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// AssertResponseMatchesSpec is a testing helper method that checks if the response body matches the schema
+// declared in the OpenAPI spec loaded with WithOpenAPISpec, for the method, path and status code of the response.
+// The path can be either the template from the spec, e.g. "/users/{id}", or a concrete one, e.g. "/users/42".
+// Responses without declared content are not validated.
+func (w *Wisent) AssertResponseMatchesSpec(tb testing.TB, method, path string, resp *http.Response) {
+	tb = w.assertionTB(tb)
+	if w.openAPISpec == nil {
+		tb.Fatalf("No OpenAPI spec provided, use WithOpenAPISpec")
+	}
+	schema, err := w.openAPISpec.responseSchema(method, path, resp.StatusCode, resp.Header.Get("Content-Type"))
+	if err != nil {
+		tb.Fatalf("Error finding response schema: %v", err)
+	}
+	if schema == nil {
+		return
+	}
+	doc := readJSON(tb, resp)
+	if violations := w.openAPISpec.validate("$", schema, doc); len(violations) > 0 {
+		tb.Fatalf("Response does not match the spec:\n%s", strings.Join(violations, "\n"))
+	}
+}
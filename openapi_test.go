@@ -0,0 +1,130 @@
+package wisent
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+const testSpec = `{
+	"openapi": "3.0.3",
+	"paths": {
+		"/users/{id}": {
+			"get": {
+				"responses": {
+					"200": {"content": {"application/json": {"schema": {"$ref": "#/components/schemas/User"}}}},
+					"404": {"description": "Not found"}
+				}
+			}
+		}
+	},
+	"components": {
+		"schemas": {
+			"User": {
+				"type": "object",
+				"required": ["id", "status"],
+				"additionalProperties": false,
+				"properties": {
+					"id": {"type": "integer"},
+					"status": {"type": "string", "enum": ["active", "closed"]},
+					"nickname": {"type": "string", "nullable": true},
+					"tags": {"type": "array", "items": {"type": "string"}}
+				}
+			}
+		}
+	}
+}`
+
+func TestOpenAPISpecValidate(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "spec.json")
+	if err := os.WriteFile(path, []byte(testSpec), 0o644); err != nil {
+		t.Fatalf("Error writing spec: %v", err)
+	}
+	spec, err := loadOpenAPISpec(path)
+	if err != nil {
+		t.Fatalf("Error loading spec: %v", err)
+	}
+
+	schema, err := spec.responseSchema("GET", "/users/42", 404, "")
+	if err != nil || schema != nil {
+		t.Fatalf("Incorrect schema of response without content, got: %v, %v, want: nil", schema, err)
+	}
+	if _, err := spec.responseSchema("GET", "/users/42", 200, "text/plain"); err == nil {
+		t.Fatalf("Expected an error for undeclared content type")
+	}
+	schema, err = spec.responseSchema("GET", "/users/42", 200, "application/json; charset=utf-8")
+	if err != nil {
+		t.Fatalf("Error finding schema: %v", err)
+	}
+
+	tests := []struct {
+		name       string
+		body       string
+		violations []string
+	}{
+		{
+			name: "valid",
+			body: `{"id": 1, "status": "active", "nickname": null, "tags": ["a"]}`,
+		},
+		{
+			name: "invalid",
+			body: `{"id": 1.5, "status": "deleted", "tags": [1], "extra": true}`,
+			violations: []string{
+				`$: unexpected field "extra"`,
+				`$.id: expected type integer, got 1.5`,
+				`$.status: expected one of ["active","closed"], got "deleted"`,
+				`$.tags[0]: expected type string, got 1`,
+			},
+		},
+		{
+			name:       "missing required",
+			body:       `{"id": 1}`,
+			violations: []string{`$: missing required field "status"`},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			doc, err := unmarshalJSON([]byte(tt.body))
+			if err != nil {
+				t.Fatalf("Error decoding body: %v", err)
+			}
+			if violations := spec.validate("$", schema, doc); !reflect.DeepEqual(violations, tt.violations) {
+				t.Fatalf("Incorrect violations, got: %q, want: %q", violations, tt.violations)
+			}
+		})
+	}
+}
+
+func TestLoadOpenAPISpecYAML(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "spec.yaml")
+	if err := os.WriteFile(path, []byte("openapi: 3.0.3\n"), 0o644); err != nil {
+		t.Fatalf("Error writing spec: %v", err)
+	}
+	if _, err := loadOpenAPISpec(path); err == nil || !strings.Contains(err.Error(), "convert the spec to JSON") {
+		t.Fatalf("Expected an error for YAML spec, got: %v", err)
+	}
+}
+
+func TestOpenAPISpecMostSpecificPath(t *testing.T) {
+	paths := map[string]any{}
+	for _, template := range []string{"/{kind}/{id}", "/users/{id}", "/{kind}/me"} {
+		paths[template] = map[string]any{"get": map[string]any{"responses": map[string]any{
+			"200": map[string]any{"content": map[string]any{"application/json": map[string]any{"schema": template}}},
+		}}}
+	}
+	spec := &openAPISpec{doc: map[string]any{"paths": paths}}
+
+	for path, expected := range map[string]string{"/users/42": "/users/{id}", "/teams/me": "/{kind}/me", "/teams/42": "/{kind}/{id}"} {
+		for range 10 {
+			schema, err := spec.responseSchema("GET", path, 200, "application/json")
+			if err != nil {
+				t.Fatalf("Error finding schema of %s: %v", path, err)
+			}
+			if schema != expected {
+				t.Fatalf("Incorrect template for %s, got: %v, want: %s", path, schema, expected)
+			}
+		}
+	}
+}
@@ -0,0 +1,336 @@
+package wisent
+
+import (
+	"context"
+	"crypto/tls"
+	"fmt"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/http/cookiejar"
+	"net/url"
+	"slices"
+	"testing"
+	"time"
+)
+
+type WisentOpt func(w *Wisent)
+
+func WithStartFunc(start StartFunc) WisentOpt { return func(w *Wisent) { w.Start = start } }
+
+func WithReadinessProbe(rp ReadinessProbe) WisentOpt {
+	return func(w *Wisent) { w.ReadinessProbe = rp }
+}
+
+func WithHttpClient(client *http.Client) WisentOpt {
+	return func(w *Wisent) { w.HttpClient = client }
+}
+
+func WithRequestWrapper(rw RequestWrapper) WisentOpt {
+	return func(w *Wisent) { w.RequestWrapper = rw }
+}
+
+func WithLogger(logger *slog.Logger) WisentOpt {
+	return func(w *Wisent) { w.Logger = logger }
+}
+
+// WithResponseDecompression transparently decompresses response bodies based on the Content-Encoding header.
+// Supported encodings are gzip and deflate. Brotli (br) is not supported, as the standard library has no decoder:
+// such responses, like other encodings, are passed through untouched, with their Content-Encoding header kept,
+// so the client should not advertise br in Accept-Encoding.
+func WithResponseDecompression() WisentOpt {
+	return func(w *Wisent) { w.decompressResponses = true }
+}
+
+// WithBaseQueryParams adds query parameters to every request created with NewRequest.
+// Parameters already present in the request URL take precedence.
+func WithBaseQueryParams(params url.Values) WisentOpt {
+	return func(w *Wisent) {
+		if w.baseQueryParams == nil {
+			w.baseQueryParams = url.Values{}
+		}
+		for key, values := range params {
+			w.baseQueryParams[key] = append(w.baseQueryParams[key], values...)
+		}
+	}
+}
+
+// WithKeepaliveDisable disables HTTP keep-alives, forcing a new connection for every request.
+// Like all transport options, it has no effect when a custom client is provided with WithHttpClient.
+func WithKeepaliveDisable() WisentOpt {
+	return func(w *Wisent) {
+		w.transportOpts = append(w.transportOpts, func(t *http.Transport) { t.DisableKeepAlives = true })
+	}
+}
+
+// WithBodySizeMetrics collects response body sizes into the provided stats.
+// The stats are populated at the end of every test or benchmark run.
+func WithBodySizeMetrics(stats *BodySizeStats) WisentOpt {
+	return func(w *Wisent) { w.bodySizeStats = stats }
+}
+
+// WithTags runs only tests that have at least one of the provided tags.
+// Other tests are skipped.
+func WithTags(tags ...string) WisentOpt {
+	return func(w *Wisent) { w.tags = append(w.tags, tags...) }
+}
+
+// WithSkipTags skips tests that have any of the provided tags.
+func WithSkipTags(tags ...string) WisentOpt {
+	return func(w *Wisent) { w.skipTags = append(w.skipTags, tags...) }
+}
+
+// WithDefaultHeaders adds headers to every request that does not already set them.
+// Headers set on a request take precedence. The provided headers are copied.
+func WithDefaultHeaders(headers http.Header) WisentOpt {
+	return func(w *Wisent) {
+		if w.defaultHeaders == nil {
+			w.defaultHeaders = http.Header{}
+		}
+		for key, values := range headers.Clone() {
+			w.defaultHeaders[http.CanonicalHeaderKey(key)] = values
+		}
+	}
+}
+
+// WithResponseHeaderTimeout sets the time to wait for response headers after the request is written.
+// Like all transport options, it has no effect when a custom client is provided with WithHttpClient.
+func WithResponseHeaderTimeout(d time.Duration) WisentOpt {
+	return func(w *Wisent) {
+		w.transportOpts = append(w.transportOpts, func(t *http.Transport) { t.ResponseHeaderTimeout = d })
+	}
+}
+
+// WithBenchmarkName labels the instance, so that results of multiple instances can be told apart.
+// The name is added to every log message and to the benchmark results.
+func WithBenchmarkName(name string) WisentOpt {
+	return func(w *Wisent) { w.benchmarkName = name }
+}
+
+// WithAuditLog writes a record of every request and its response to the writer.
+// Each record contains the timestamp, method, URL, status code, latency and SHA-256 hashes of both bodies.
+// The format is either AuditFormatJSONL (one JSON object per line) or AuditFormatCSV.
+// Unknown formats fall back to AuditFormatJSONL.
+//
+// Auditing requires both bodies to be buffered in memory. An error writing the CSV header is reported
+// through the option errors, and errors writing records are logged as warnings.
+func WithAuditLog(out io.Writer, format string) WisentOpt {
+	return func(w *Wisent) {
+		l, err := newAuditLog(out, format)
+		if err != nil {
+			w.optErrs = append(w.optErrs, fmt.Errorf("creating audit log: %w", err))
+			return
+		}
+		w.auditLog = l
+	}
+}
+
+// WithTLSConfig sets the TLS configuration of the default client, e.g. to trust self-signed certificates.
+// The default timeouts are kept. Like all transport options, it has no effect when a custom client
+// is provided with WithHttpClient, in which case a warning is logged.
+func WithTLSConfig(cfg *tls.Config) WisentOpt {
+	return func(w *Wisent) {
+		w.transportOpts = append(w.transportOpts, func(t *http.Transport) { t.TLSClientConfig = cfg.Clone() })
+	}
+}
+
+// WithProxy routes requests of the default client through the proxy at the given URL.
+// Supported schemes are http, https and socks5. An invalid URL is reported through the option errors,
+// so as with other invalid options, New panics with all of them. Like all transport options, it has no effect when
+// a custom client is provided with WithHttpClient.
+func WithProxy(proxyURL string) WisentOpt {
+	return func(w *Wisent) {
+		u, err := url.Parse(proxyURL)
+		if err == nil && !slices.Contains([]string{"http", "https", "socks5", "socks5h"}, u.Scheme) {
+			err = fmt.Errorf("unsupported scheme %q", u.Scheme)
+		}
+		if err != nil {
+			w.optErrs = append(w.optErrs, fmt.Errorf("parsing proxy url: %w", err))
+			return
+		}
+		w.transportOpts = append(w.transportOpts, func(t *http.Transport) { t.Proxy = http.ProxyURL(u) })
+	}
+}
+
+// WithRequestDedup calls onDuplicate for every request that was already sent by this instance,
+// as identified by its method, URL and body. It helps to find bugs in test logic.
+// Retries of a test request with Test.Retry are not reported. It is not meant for benchmarks,
+// which send the same request repeatedly.
+func WithRequestDedup(onDuplicate func(req *http.Request)) WisentOpt {
+	return func(w *Wisent) { w.onDuplicate = onDuplicate }
+}
+
+// WithRoundTripMiddleware wraps the transport of the HTTP client with the provided middlewares,
+// e.g. to integrate tracing or metrics libraries. Middlewares are applied in order,
+// so the last one is the outermost. A custom client provided with WithHttpClient is copied, not modified.
+func WithRoundTripMiddleware(middlewares ...func(http.RoundTripper) http.RoundTripper) WisentOpt {
+	return func(w *Wisent) { w.transportWrappers = append(w.transportWrappers, middlewares...) }
+}
+
+// WithTestTimeout makes tests respect the deadline of the test binary, as set with the -timeout flag.
+// The deadline applies to the app started with StartFunc, the readiness probe, and all test requests.
+func WithTestTimeout(t *testing.T) WisentOpt {
+	return func(w *Wisent) {
+		if deadline, ok := t.Deadline(); ok {
+			w.deadline = deadline
+		}
+	}
+}
+
+// WithCookieJar sets the cookie jar of the HTTP client, so that cookies set by one request,
+// e.g. a login, are sent with the following ones. A custom client provided with WithHttpClient is copied, not modified.
+func WithCookieJar(jar http.CookieJar) WisentOpt {
+	return func(w *Wisent) { w.cookieJar = jar }
+}
+
+// WithDefaultCookieJar is like WithCookieJar, but uses a new in-memory cookie jar.
+func WithDefaultCookieJar() WisentOpt {
+	return func(w *Wisent) {
+		jar, err := cookiejar.New(nil)
+		if err != nil {
+			w.optErrs = append(w.optErrs, fmt.Errorf("creating cookie jar: %w", err))
+			return
+		}
+		w.cookieJar = jar
+	}
+}
+
+// WithMetricsSamplingRate records metric samples, i.e. latencies and body sizes, only for the given fraction
+// of requests, to reduce overhead in high-frequency benchmarks. Request and error counts stay exact.
+// Rates outside of (0, 1) record every request.
+func WithMetricsSamplingRate(rate float64) WisentOpt {
+	return func(w *Wisent) { w.samplingRate = rate }
+}
+
+// WithFailFast stops running tests after the first failed one.
+func WithFailFast() WisentOpt {
+	return WithFailFastAfter(1)
+}
+
+// WithFailFastAfter stops running tests after n of them failed.
+func WithFailFastAfter(n int) WisentOpt {
+	return func(w *Wisent) { w.failFastAfter = n }
+}
+
+// WithReadinessProbeOnce runs the readiness probe only on the first run of the instance,
+// e.g. when the same instance is used for several Test calls against an already running app.
+func WithReadinessProbeOnce() WisentOpt {
+	return func(w *Wisent) { w.readinessProbeOnce = true }
+}
+
+// WithUserAgent sets the User-Agent header of every request that does not already set it.
+// It takes precedence over a User-Agent set with WithDefaultHeaders, in which case a warning is logged.
+func WithUserAgent(ua string) WisentOpt {
+	return func(w *Wisent) { w.userAgent = ua }
+}
+
+// WithBaseContext sets the context that test and benchmark runs derive their context from,
+// so that its values, e.g. tenant or trace IDs, reach StartFunc, the readiness probe and test requests.
+// Wisent manages cancellation of the derived context itself, so the provided context must not be cancelled externally.
+func WithBaseContext(ctx context.Context) WisentOpt {
+	return func(w *Wisent) { w.baseCtx = ctx }
+}
+
+// WithSuccessStatusRange fails every test whose response status code is outside of [lo, hi]
+// before PostRequest and AssertResponse are run, as if the request itself failed.
+// It applies to tests only. Benchmarks do not check it, their responses are checked by Benchmark.AssertResponse.
+func WithSuccessStatusRange(lo, hi int) WisentOpt {
+	return func(w *Wisent) { w.successStatus = &[2]int{lo, hi} }
+}
+
+// WithDisableCompression stops the default client from requesting gzip compression and decompressing responses,
+// so that the raw Content-Encoding header and body are visible to assertions.
+// Like all transport options, it has no effect when a custom client is provided with WithHttpClient.
+func WithDisableCompression() WisentOpt {
+	return func(w *Wisent) {
+		w.transportOpts = append(w.transportOpts, func(t *http.Transport) { t.DisableCompression = true })
+	}
+}
+
+// WithHTTPTransportWrapper wraps the transport of the HTTP client with a single wrapper,
+// such as the ones provided by tracing or retry libraries. It can be passed multiple times,
+// and composes with WithRoundTripMiddleware in the order the options are given.
+func WithHTTPTransportWrapper(wrap func(http.RoundTripper) http.RoundTripper) WisentOpt {
+	return WithRoundTripMiddleware(wrap)
+}
+
+// WithMaxResponseBodySize limits how many bytes of a response body can be read, e.g. by PostRequest,
+// AssertResponse, the assertion helpers, the audit log and HAR capture, to avoid buffering huge bodies
+// of mis-configured endpoints. A warning is logged when a body is truncated. If empty, bodies are not limited.
+func WithMaxResponseBodySize(n int64) WisentOpt {
+	return func(w *Wisent) { w.maxResponseBodySize = n }
+}
+
+// WithHTTPClientClone gives every test its own copy of the HTTP client, with a fresh cookie jar,
+// so that session state does not leak between tests, e.g. parallel ones.
+func WithHTTPClientClone() WisentOpt {
+	return func(w *Wisent) { w.cloneClient = true }
+}
+
+// WithFollowRedirects sets whether the default client follows redirects. If false, the redirect response itself,
+// e.g. 301 Moved Permanently with its Location header, is passed to PostRequest and AssertResponse.
+// A custom client provided with WithHttpClient is not modified, and its CheckRedirect is used instead.
+func WithFollowRedirects(follow bool) WisentOpt {
+	return func(w *Wisent) { w.followRedirects = &follow }
+}
+
+// WithContentLengthFix buffers request bodies before sending them, so that Content-Length is always set,
+// e.g. for requests with a body of unknown size, which are otherwise sent with chunked encoding.
+func WithContentLengthFix() WisentOpt {
+	return func(w *Wisent) { w.contentLengthFix = true }
+}
+
+// WithResponseDecoder wraps every response body with the reader returned by fn, e.g. to handle
+// custom content encodings or encryption, so that all assertion helpers see the decoded body.
+// Body size metrics count the bytes before decoding, while WithMaxResponseBodySize limits the decoded body.
+func WithResponseDecoder(fn func(body io.ReadCloser) (io.ReadCloser, error)) WisentOpt {
+	return func(w *Wisent) { w.responseDecoder = fn }
+}
+
+// WithHTTP2Transport makes the default client attempt HTTP/2, which is otherwise disabled by its custom dialer.
+// HTTP/2 is negotiated with TLS, so the server must be reached over HTTPS, e.g. with WithTLSConfig.
+// A warning is logged by New if the base URL is not HTTPS, and once if a response still uses another protocol.
+// Like all transport options, it has no effect when a custom client is provided with WithHttpClient.
+func WithHTTP2Transport() WisentOpt {
+	return func(w *Wisent) {
+		w.http2 = true
+		w.transportOpts = append(w.transportOpts, func(t *http.Transport) { t.ForceAttemptHTTP2 = true })
+	}
+}
+
+// WithOpenAPISpec loads an OpenAPI 3.x spec in JSON format, used by AssertResponseMatchesSpec
+// to validate response bodies against the declared schemas.
+// YAML specs are not supported and must be converted to JSON first, e.g. with yq -o=json.
+// As with other invalid options, New panics if the spec cannot be read or decoded.
+func WithOpenAPISpec(specPath string) WisentOpt {
+	return func(w *Wisent) {
+		spec, err := loadOpenAPISpec(specPath)
+		if err != nil {
+			w.optErrs = append(w.optErrs, fmt.Errorf("loading OpenAPI spec: %w", err))
+			return
+		}
+		w.openAPISpec = spec
+	}
+}
+
+// WithHARCapture records every request and response, including their bodies, so that they can be
+// written to a HAR file with WriteHAR. As bodies are buffered in memory, it is best avoided in long benchmarks.
+// Response bodies are read while the request is performed, so benchmark latencies include reading them,
+// while the timings in the HAR file do not.
+func WithHARCapture() WisentOpt {
+	return func(w *Wisent) { w.harLog = &harLog{} }
+}
+
+// WithBenchmarkBarrier makes BenchmarkParallel goroutines wait for each other before sending their first request,
+// so that the load is concurrent from the start, instead of the first goroutines running without competition.
+// The number of goroutines must be set with Benchmark.Concurrency or BenchmarkParallelN, not b.SetParallelism.
+func WithBenchmarkBarrier() WisentOpt {
+	return func(w *Wisent) { w.benchmarkBarrier = true }
+}
+
+// WithMaxTestDuration limits the duration of a whole Test run. Once it is exceeded, requests fail
+// with context.DeadlineExceeded, the remaining tests are not run, and the run fails.
+// Unlike WithTestTimeout, the deadline does not apply to the app started with StartFunc.
+func WithMaxTestDuration(d time.Duration) WisentOpt {
+	return func(w *Wisent) { w.maxTestDuration = d }
+}
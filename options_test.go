@@ -0,0 +1,392 @@
+package wisent_test
+
+import (
+	"bufio"
+	"context"
+	"crypto/sha256"
+	"crypto/tls"
+	"crypto/x509"
+	"encoding/csv"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"os/exec"
+	"slices"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/ttyobiwan/wisent"
+)
+
+func TestAuditLog(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method == http.MethodPost {
+			w.WriteHeader(http.StatusCreated)
+		}
+		w.Write([]byte("ok"))
+	}))
+	defer server.Close()
+	hash := func(s string) string {
+		sum := sha256.Sum256([]byte(s))
+		return hex.EncodeToString(sum[:])
+	}
+	run := func(format string) string {
+		var out strings.Builder
+		w := wisent.New(server.URL, wisent.WithAuditLog(&out, format))
+		w.Test(t, []wisent.Test{
+			{
+				Name:           "create",
+				Request:        w.NewRequest(http.MethodPost, "/items", strings.NewReader("payload")),
+				AssertResponse: func(resp *http.Response, err error) { w.AssertResponseBody(t, "ok", resp) },
+			},
+		})
+		return out.String()
+	}
+
+	var record struct {
+		Method           string  `json:"method"`
+		URL              string  `json:"url"`
+		StatusCode       int     `json:"status_code"`
+		LatencyMs        float64 `json:"latency_ms"`
+		RequestBodyHash  string  `json:"request_body_hash"`
+		ResponseBodyHash string  `json:"response_body_hash"`
+	}
+	if err := json.Unmarshal([]byte(run(wisent.AuditFormatJSONL)), &record); err != nil {
+		t.Fatalf("Error decoding JSONL record: %v", err)
+	}
+	if record.Method != http.MethodPost || record.URL != server.URL+"/items" || record.StatusCode != http.StatusCreated ||
+		record.RequestBodyHash != hash("payload") || record.ResponseBodyHash != hash("ok") || record.LatencyMs <= 0 {
+		t.Fatalf("Incorrect JSONL record, got: %+v", record)
+	}
+
+	rows, err := csv.NewReader(strings.NewReader(run(wisent.AuditFormatCSV))).ReadAll()
+	if err != nil {
+		t.Fatalf("Error decoding CSV records: %v", err)
+	}
+	if len(rows) != 2 || rows[0][0] != "timestamp" || rows[1][1] != http.MethodPost || rows[1][3] != "201" || rows[1][5] != hash("payload") {
+		t.Fatalf("Incorrect CSV records, got: %q", rows)
+	}
+}
+
+func TestRequestDedup(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(http.ResponseWriter, *http.Request) {}))
+	defer server.Close()
+
+	var duplicates []string
+	w := wisent.New(server.URL, wisent.WithRequestDedup(func(req *http.Request) {
+		body := ""
+		if req.Body != nil {
+			data, _ := io.ReadAll(req.Body)
+			body = string(data)
+		}
+		duplicates = append(duplicates, req.Method+" "+req.URL.Path+" "+body)
+	}))
+	tests := []wisent.Test{}
+	for i, req := range []*http.Request{
+		w.NewRequest(http.MethodGet, "/items", nil),
+		w.NewRequest(http.MethodPost, "/items", strings.NewReader("a")),
+		w.NewRequest(http.MethodPost, "/items", strings.NewReader("b")),
+		w.NewRequest(http.MethodPost, "/items", strings.NewReader("a")),
+		w.NewRequest(http.MethodGet, "/items", nil),
+	} {
+		tests = append(tests, wisent.Test{
+			Name:           fmt.Sprintf("%d %s", i, req.Method),
+			Request:        req,
+			AssertResponse: func(_ *http.Response, err error) { w.AssertResponseError(t, err) },
+		})
+	}
+	w.Test(t, tests)
+
+	if want := []string{"POST /items a", "GET /items "}; !slices.Equal(duplicates, want) {
+		t.Fatalf("Incorrect duplicates, got: %q, want: %q", duplicates, want)
+	}
+}
+
+func TestTags(t *testing.T) {
+	var paths []string
+	server := httptest.NewServer(http.HandlerFunc(func(_ http.ResponseWriter, r *http.Request) {
+		paths = append(paths, r.URL.Path)
+	}))
+	defer server.Close()
+
+	w := wisent.New(server.URL, wisent.WithTags("smoke"), wisent.WithSkipTags("slow"))
+	tests := []wisent.Test{}
+	for path, tags := range map[string][]string{
+		"/smoke":      {"smoke"},
+		"/untagged":   nil,
+		"/slow-smoke": {"smoke", "slow"},
+		"/other":      {"other"},
+	} {
+		tests = append(tests, wisent.Test{
+			Name:           path,
+			Request:        w.NewRequest(http.MethodGet, path, nil),
+			Tags:           tags,
+			AssertResponse: func(_ *http.Response, err error) { w.AssertResponseError(t, err) },
+		})
+	}
+	w.Test(t, tests)
+
+	if want := []string{"/smoke"}; !slices.Equal(paths, want) {
+		t.Fatalf("Incorrect tests run, got: %q, want: %q", paths, want)
+	}
+}
+
+// TestFailFast runs a failing test in a child process, as it would fail this test otherwise.
+func TestFailFast(t *testing.T) {
+	if os.Getenv("WISENT_FAIL_FAST") != "" {
+		runFailFastTests(t)
+		return
+	}
+
+	cmd := exec.Command(os.Args[0], "-test.run=^TestFailFast$", "-test.v")
+	cmd.Env = append(os.Environ(), "WISENT_FAIL_FAST=1")
+	out, err := cmd.CombinedOutput()
+	if err == nil {
+		t.Fatalf("Expected the failing test to fail\nOutput: %s", out)
+	}
+	if !strings.Contains(string(out), "Unsuccessful status code, got: 404, want: [200, 299]") {
+		t.Fatalf("Status code not checked\nOutput: %s", out)
+	}
+	if strings.Contains(string(out), "TestFailFast/second") {
+		t.Fatalf("Expected tests to stop after the first failure\nOutput: %s", out)
+	}
+}
+
+func runFailFastTests(t *testing.T) {
+	server := httptest.NewServer(http.NotFoundHandler())
+	defer server.Close()
+
+	w := wisent.New(server.URL, wisent.WithFailFast(), wisent.WithSuccessStatusRange(200, 299))
+	tests := []wisent.Test{}
+	for _, name := range []string{"first", "second"} {
+		tests = append(tests, wisent.Test{
+			Name:           name,
+			Request:        w.NewRequest(http.MethodGet, "/", nil),
+			AssertResponse: func(_ *http.Response, err error) { w.AssertResponseError(t, err) },
+		})
+	}
+	w.Test(t, tests)
+}
+
+func TestCookieJar(t *testing.T) {
+	mux := http.NewServeMux()
+	mux.HandleFunc("POST /login", func(w http.ResponseWriter, _ *http.Request) {
+		http.SetCookie(w, &http.Cookie{Name: "session", Value: "abc", Path: "/"})
+	})
+	mux.HandleFunc("GET /me", func(w http.ResponseWriter, r *http.Request) {
+		if _, err := r.Cookie("session"); err != nil {
+			w.WriteHeader(http.StatusUnauthorized)
+		}
+	})
+	server := httptest.NewServer(mux)
+	defer server.Close()
+
+	run := func(t *testing.T, me int, opts ...wisent.WisentOpt) {
+		w := wisent.New(server.URL, append(opts, wisent.WithDefaultCookieJar())...)
+		w.Test(t, []wisent.Test{
+			{
+				Name:    "login",
+				Request: w.NewRequest(http.MethodPost, "/login", nil),
+				AssertResponse: func(resp *http.Response, err error) {
+					w.AssertResponseError(t, err)
+					w.AssertResponseCookieValue(t, "session", "abc", resp)
+				},
+			},
+			{
+				Name:    "me",
+				Request: w.NewRequest(http.MethodGet, "/me", nil),
+				AssertResponse: func(resp *http.Response, err error) {
+					w.AssertResponseError(t, err)
+					w.AssertResponseStatusCode(t, me, resp)
+				},
+			},
+		})
+	}
+
+	t.Run("shared", func(t *testing.T) { run(t, http.StatusOK) })
+	t.Run("cloned per test", func(t *testing.T) { run(t, http.StatusUnauthorized, wisent.WithHTTPClientClone()) })
+}
+
+func TestRequestOptions(t *testing.T) {
+	var got *http.Request
+	var body string
+	server := httptest.NewServer(http.HandlerFunc(func(_ http.ResponseWriter, r *http.Request) {
+		data, _ := io.ReadAll(r.Body)
+		got, body = r, string(data)
+	}))
+	defer server.Close()
+
+	var calls []string
+	middleware := func(name string) func(http.RoundTripper) http.RoundTripper {
+		return func(next http.RoundTripper) http.RoundTripper {
+			return roundTripFunc(func(req *http.Request) (*http.Response, error) {
+				calls = append(calls, name)
+				return next.RoundTrip(req)
+			})
+		}
+	}
+	w := wisent.New(server.URL,
+		wisent.WithDefaultHeaders(http.Header{"X-Tenant": {"default"}, "X-Env": {"test"}, "User-Agent": {"default"}}),
+		wisent.WithUserAgent("wisent-test"),
+		wisent.WithKeepaliveDisable(),
+		wisent.WithDisableCompression(),
+		wisent.WithContentLengthFix(),
+		wisent.WithRoundTripMiddleware(middleware("inner")),
+		wisent.WithHTTPTransportWrapper(middleware("outer")),
+	)
+	req := w.NewRequestWithHeaders(http.MethodPost, "/", io.MultiReader(strings.NewReader("pay"), strings.NewReader("load")),
+		http.Header{"X-Tenant": {"explicit"}})
+	w.Test(t, []wisent.Test{
+		{
+			Name:           "request",
+			Request:        req,
+			AssertResponse: func(_ *http.Response, err error) { w.AssertResponseError(t, err) },
+		},
+	})
+
+	for key, want := range map[string]string{"X-Tenant": "explicit", "X-Env": "test", "User-Agent": "wisent-test", "Accept-Encoding": ""} {
+		if value := got.Header.Get(key); value != want {
+			t.Errorf("Incorrect header %q, got: %q, want: %q", key, value, want)
+		}
+	}
+	if !got.Close {
+		t.Errorf("Expected the connection to be closed")
+	}
+	if got.ContentLength != 7 || len(got.TransferEncoding) > 0 || body != "payload" {
+		t.Errorf("Incorrect body, got: %q of length %d with transfer encoding %q", body, got.ContentLength, got.TransferEncoding)
+	}
+	if want := []string{"outer", "inner"}; !slices.Equal(calls, want) {
+		t.Errorf("Incorrect order of middlewares, got: %q, want: %q", calls, want)
+	}
+}
+
+func TestTransportOptions(t *testing.T) {
+	t.Run("follow redirects", func(t *testing.T) {
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if r.URL.Path == "/old" {
+				http.Redirect(w, r, "/new", http.StatusMovedPermanently)
+			}
+		}))
+		defer server.Close()
+
+		for follow, want := range map[bool]int{true: http.StatusOK, false: http.StatusMovedPermanently} {
+			w := wisent.New(server.URL, wisent.WithFollowRedirects(follow))
+			w.Test(t, []wisent.Test{
+				{
+					Name:    "redirect",
+					Request: w.NewRequest(http.MethodGet, "/old", nil),
+					AssertResponse: func(resp *http.Response, err error) {
+						w.AssertResponseError(t, err)
+						w.AssertResponseStatusCode(t, want, resp)
+					},
+				},
+			})
+		}
+	})
+
+	t.Run("response header timeout", func(t *testing.T) {
+		server := httptest.NewServer(http.HandlerFunc(func(http.ResponseWriter, *http.Request) {
+			time.Sleep(200 * time.Millisecond)
+		}))
+		defer server.Close()
+
+		w := wisent.New(server.URL, wisent.WithResponseHeaderTimeout(20*time.Millisecond))
+		w.Test(t, []wisent.Test{
+			{
+				Name:    "slow",
+				Request: w.NewRequest(http.MethodGet, "/", nil),
+				AssertResponse: func(_ *http.Response, err error) {
+					if err == nil || !strings.Contains(err.Error(), "timeout awaiting response headers") {
+						t.Errorf("Expected a response header timeout, got: %v", err)
+					}
+				},
+			},
+		})
+	})
+
+	t.Run("TLS config", func(t *testing.T) {
+		server := httptest.NewTLSServer(http.HandlerFunc(func(http.ResponseWriter, *http.Request) {}))
+		defer server.Close()
+		roots := x509.NewCertPool()
+		roots.AddCert(server.Certificate())
+
+		w := wisent.New(server.URL, wisent.WithTLSConfig(&tls.Config{RootCAs: roots}))
+		w.Test(t, []wisent.Test{
+			{
+				Name:           "trusted",
+				Request:        w.NewRequest(http.MethodGet, "/", nil),
+				AssertResponse: func(_ *http.Response, err error) { w.AssertResponseError(t, err) },
+			},
+		})
+	})
+
+	t.Run("proxy", func(t *testing.T) {
+		var proxied string
+		proxy := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			proxied = r.URL.String()
+			w.Write([]byte("proxied"))
+		}))
+		defer proxy.Close()
+
+		w := wisent.New("http://wisent.invalid", wisent.WithProxy(proxy.URL))
+		w.Test(t, []wisent.Test{
+			{
+				Name:    "through proxy",
+				Request: w.NewRequest(http.MethodGet, "/items", nil),
+				AssertResponse: func(resp *http.Response, err error) {
+					w.AssertResponseError(t, err)
+					w.AssertResponseBody(t, "proxied", resp)
+				},
+			},
+		})
+		if proxied != "http://wisent.invalid/items" {
+			t.Fatalf("Incorrect proxied URL, got: %q, want: %q", proxied, "http://wisent.invalid/items")
+		}
+	})
+
+	t.Run("response decoder", func(t *testing.T) {
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+			w.Write([]byte("DLROW"))
+		}))
+		defer server.Close()
+
+		w := wisent.New(server.URL, wisent.WithResponseDecoder(func(body io.ReadCloser) (io.ReadCloser, error) {
+			data, err := io.ReadAll(bufio.NewReader(body))
+			body.Close()
+			slices.Reverse(data)
+			return io.NopCloser(strings.NewReader(strings.ToLower(string(data)))), err
+		}))
+		w.Test(t, []wisent.Test{
+			{
+				Name:           "decoded",
+				Request:        w.NewRequest(http.MethodGet, "/", nil),
+				AssertResponse: func(resp *http.Response, err error) { w.AssertResponseBody(t, "world", resp) },
+			},
+		})
+	})
+
+	t.Run("base context", func(t *testing.T) {
+		type key struct{}
+		server := httptest.NewServer(http.HandlerFunc(func(http.ResponseWriter, *http.Request) {}))
+		defer server.Close()
+
+		var value any
+		w := wisent.New(server.URL, wisent.WithBaseContext(context.WithValue(context.Background(), key{}, "tenant")))
+		w.Test(t, []wisent.Test{
+			{
+				Name:           "context",
+				Request:        w.NewRequest(http.MethodGet, "/", nil),
+				PreRequest:     func(req *http.Request) { value = req.Context().Value(key{}) },
+				AssertResponse: func(_ *http.Response, err error) { w.AssertResponseError(t, err) },
+			},
+		})
+		if value != "tenant" {
+			t.Fatalf("Incorrect context value, got: %v, want: %v", value, "tenant")
+		}
+	})
+}
@@ -0,0 +1,139 @@
+package wisent
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"sync"
+	"testing"
+)
+
+// errAssertionStopped is raised by a quiet recordingTB on a fatal failure, to stop the assertions of an attempt.
+var errAssertionStopped = errors.New("assertion stopped the attempt")
+
+// recordingTB is a testing.TB that records the failures reported through it, and passes them on to the wrapped test.
+// A quiet recorder only records them, e.g. for attempts of a test that are going to be retried.
+type recordingTB struct {
+	testing.TB
+	quiet bool
+
+	mu       sync.Mutex
+	failed   bool
+	failures []string
+}
+
+func (r *recordingTB) record(message string) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.failed = true
+	if message = strings.TrimSuffix(message, "\n"); message != "" {
+		r.failures = append(r.failures, message)
+	}
+}
+
+// messages returns the messages of the failures recorded so far.
+func (r *recordingTB) messages() []string {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return append([]string(nil), r.failures...)
+}
+
+func (r *recordingTB) Failed() bool {
+	r.mu.Lock()
+	failed := r.failed
+	r.mu.Unlock()
+	return failed || !r.quiet && r.TB.Failed()
+}
+
+func (r *recordingTB) Fail() {
+	r.record("")
+	if !r.quiet {
+		r.TB.Fail()
+	}
+}
+
+func (r *recordingTB) FailNow() {
+	r.record("")
+	r.stop()
+}
+
+func (r *recordingTB) Error(args ...any) {
+	r.record(fmt.Sprintln(args...))
+	if !r.quiet {
+		r.TB.Error(args...)
+	}
+}
+
+func (r *recordingTB) Errorf(format string, args ...any) {
+	r.record(fmt.Sprintf(format, args...))
+	if !r.quiet {
+		r.TB.Errorf(format, args...)
+	}
+}
+
+func (r *recordingTB) Fatal(args ...any) {
+	r.record(fmt.Sprintln(args...))
+	if !r.quiet {
+		r.TB.Fatal(args...)
+	}
+	panic(errAssertionStopped)
+}
+
+func (r *recordingTB) Fatalf(format string, args ...any) {
+	r.record(fmt.Sprintf(format, args...))
+	if !r.quiet {
+		r.TB.Fatalf(format, args...)
+	}
+	panic(errAssertionStopped)
+}
+
+func (r *recordingTB) stop() {
+	if !r.quiet {
+		r.TB.FailNow()
+	}
+	panic(errAssertionStopped)
+}
+
+// assertionRouter redirects assertions made on the test passed to Test, which AssertResponse functions close over,
+// to the recorder of the subtest that is running. This way failures are attributed to the subtest they belong to.
+type assertionRouter struct {
+	mu      sync.Mutex
+	targets map[testing.TB]testing.TB
+}
+
+// route redirects assertions made on one test to another, until the returned function is called.
+func (r *assertionRouter) route(from, to testing.TB) (restore func()) {
+	if r == nil {
+		return func() {}
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.targets == nil {
+		r.targets = map[testing.TB]testing.TB{}
+	}
+	prev, ok := r.targets[from]
+	r.targets[from] = to
+	return func() {
+		r.mu.Lock()
+		defer r.mu.Unlock()
+		if ok {
+			r.targets[from] = prev
+		} else {
+			delete(r.targets, from)
+		}
+	}
+}
+
+// assertionTB returns the test that assertions made on tb should report to.
+// It is called by every assertion helper.
+func (w *Wisent) assertionTB(tb testing.TB) testing.TB {
+	if w.assertionRouter == nil {
+		return tb
+	}
+	w.assertionRouter.mu.Lock()
+	defer w.assertionRouter.mu.Unlock()
+	if to, ok := w.assertionRouter.targets[tb]; ok {
+		return to
+	}
+	return tb
+}
@@ -0,0 +1,62 @@
+package wisent
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestPerformWithRetryAssertions(t *testing.T) {
+	requests, readyAfter := 0, 3
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		requests++
+		if requests >= readyAfter {
+			w.Write([]byte("ready"))
+		}
+	}))
+	defer server.Close()
+	w := New(server.URL)
+
+	// parent stands for the test passed to Test, which AssertResponse closes over.
+	parent := &recordingTB{TB: t, quiet: true}
+	run := func(tt Test) (rec *recordingTB, stopped bool) {
+		rec = &recordingTB{TB: t, quiet: true}
+		defer w.assertionRouter.route(parent, rec)()
+		defer func() {
+			if r := recover(); r != nil {
+				if r != errAssertionStopped {
+					panic(r)
+				}
+				stopped = true
+			}
+		}()
+		w.performWithRetry(parent, rec, tt, tt.Request)
+		return rec, false
+	}
+	assertReady := func(resp *http.Response, err error) {
+		w.AssertResponseError(parent, err)
+		w.AssertResponseBody(parent, "ready", resp)
+	}
+
+	rec, stopped := run(Test{Name: "eventually ready", Request: w.NewRequest(http.MethodGet, "/", nil), Retry: 3, AssertResponse: assertReady})
+	if stopped || rec.Failed() || parent.Failed() {
+		t.Fatalf("Expected the test to pass after retries, got failures: %q, %q", rec.messages(), parent.messages())
+	}
+	if requests != 3 {
+		t.Fatalf("Incorrect number of requests, got: %v, want: %v", requests, 3)
+	}
+
+	requests, readyAfter = 0, 100
+	rec, stopped = run(Test{Name: "never ready", Request: w.NewRequest(http.MethodGet, "/", nil), Retry: 2, AssertResponse: assertReady})
+	if !stopped || parent.Failed() {
+		t.Fatalf("Expected the test to stop with a failure of its own")
+	}
+	failures := rec.messages()
+	if len(failures) != 1 || !strings.Contains(failures[0], `Test "never ready" still failing after 3 attempts, last assertion failure: Body mismatch`) {
+		t.Fatalf("Incorrect failures, got: %q", failures)
+	}
+	if requests != 3 {
+		t.Fatalf("Incorrect number of requests, got: %v, want: %v", requests, 3)
+	}
+}
@@ -0,0 +1,133 @@
+package wisent
+
+import (
+	"encoding/xml"
+	"fmt"
+	"os"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+// JUnitReporter writes results of tests to a JUnit XML file, which can be consumed by most CI systems.
+type JUnitReporter struct {
+	outputPath string
+
+	mu      sync.Mutex
+	results []junitTestCase
+}
+
+// NewJUnitReporter creates a JUnitReporter that writes the report to the given path.
+func NewJUnitReporter(outputPath string) *JUnitReporter {
+	return &JUnitReporter{outputPath: outputPath}
+}
+
+// Listen instruments the tests, so that their results are recorded.
+// It must be called before the tests are run. The tests are modified in place, by hooking into their subtests.
+// The report is written when the test and all its subtests complete.
+//
+// A test is reported as failed when its subtest fails, e.g. because of an assertion, a setup error or a timeout,
+// with the messages of its failures. Assertions made on t are attributed to the test that is running,
+// except for parallel tests, whose assertions cannot be told apart.
+// Tests skipped with t.Skip, or never run at all, are reported as skipped.
+func (r *JUnitReporter) Listen(t *testing.T, tests []Test) {
+	suiteName := t.Name()
+	suiteStart := time.Now()
+
+	for i := range tests {
+		name := tests[i].Name
+		tests[i].report = func(subtest *testing.T, elapsed time.Duration, failures []string) {
+			tc := junitTestCase{Name: name, ClassName: suiteName, Time: seconds(elapsed)}
+			switch {
+			case subtest.Failed():
+				message := strings.Join(failures, "\n")
+				if message == "" {
+					message = "Test failed"
+				}
+				tc.Failure = &junitFailure{Message: message}
+			case subtest.Skipped():
+				tc.Skipped = &struct{}{}
+			}
+			r.record(tc)
+		}
+	}
+
+	t.Cleanup(func() {
+		if err := r.write(suiteName, tests, time.Since(suiteStart)); err != nil {
+			t.Errorf("Error writing JUnit report: %v", err)
+		}
+	})
+}
+
+func (r *JUnitReporter) record(tc junitTestCase) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.results = append(r.results, tc)
+}
+
+func (r *JUnitReporter) write(suiteName string, tests []Test, duration time.Duration) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	suite := junitTestSuite{
+		Name:      suiteName,
+		Tests:     len(tests),
+		Time:      seconds(duration),
+		Timestamp: time.Now().Add(-duration).Format(time.RFC3339),
+	}
+	recorded := make(map[string]bool, len(r.results))
+	for _, tc := range r.results {
+		if tc.Failure != nil {
+			suite.Failures++
+		}
+		if tc.Skipped != nil {
+			suite.Skipped++
+		}
+		recorded[tc.Name] = true
+		suite.TestCases = append(suite.TestCases, tc)
+	}
+	for _, tt := range tests {
+		if !recorded[tt.Name] {
+			suite.Skipped++
+			suite.TestCases = append(suite.TestCases, junitTestCase{Name: tt.Name, ClassName: suiteName, Time: seconds(0), Skipped: &struct{}{}})
+		}
+	}
+
+	data, err := xml.MarshalIndent(suite, "", "  ")
+	if err != nil {
+		return fmt.Errorf("encoding report: %w", err)
+	}
+	if err := os.WriteFile(r.outputPath, append([]byte(xml.Header), data...), 0o644); err != nil {
+		return fmt.Errorf("writing report: %w", err)
+	}
+	return nil
+}
+
+// seconds formats a duration the way JUnit reports expect it.
+func seconds(d time.Duration) string {
+	return fmt.Sprintf("%.3f", d.Seconds())
+}
+
+type junitTestSuite struct {
+	XMLName   xml.Name        `xml:"testsuite"`
+	Name      string          `xml:"name,attr"`
+	Tests     int             `xml:"tests,attr"`
+	Failures  int             `xml:"failures,attr"`
+	Skipped   int             `xml:"skipped,attr"`
+	Time      string          `xml:"time,attr"`
+	Timestamp string          `xml:"timestamp,attr"`
+	TestCases []junitTestCase `xml:"testcase"`
+}
+
+type junitTestCase struct {
+	Name      string        `xml:"name,attr"`
+	ClassName string        `xml:"classname,attr"`
+	Time      string        `xml:"time,attr"`
+	Failure   *junitFailure `xml:"failure,omitempty"`
+	Skipped   *struct{}     `xml:"skipped,omitempty"`
+}
+
+type junitFailure struct {
+	Message string `xml:"message,attr"`
+}
@@ -0,0 +1,133 @@
+package wisent_test
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/ttyobiwan/wisent"
+)
+
+func TestJUnitReporter(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		w.Write([]byte("ok"))
+	}))
+	defer server.Close()
+
+	path := filepath.Join(t.TempDir(), "report.xml")
+	w := wisent.New(server.URL, wisent.WithSkipTags("skipped"))
+	reporter := wisent.NewJUnitReporter(path)
+
+	t.Run("suite", func(t *testing.T) {
+		tests := []wisent.Test{
+			{
+				Name:    "passing",
+				Request: w.NewRequest(http.MethodGet, "/", nil),
+				AssertResponse: func(resp *http.Response, err error) {
+					w.AssertResponseError(t, err)
+					w.AssertResponseBody(t, "ok", resp)
+				},
+			},
+			{
+				Name:           "skipped",
+				Request:        w.NewRequest(http.MethodGet, "/", nil),
+				AssertResponse: func(*http.Response, error) {},
+				Tags:           []string{"skipped"},
+			},
+			{
+				Name:    "expected request error",
+				Request: w.NewRequest(http.MethodGet, "/", nil),
+				PreRequest: func(req *http.Request) {
+					req.URL.Host = "127.0.0.1:1"
+				},
+				AssertResponse: func(_ *http.Response, err error) {
+					if err == nil {
+						t.Fatalf("Expected a request error")
+					}
+				},
+			},
+			{
+				Name:           "skipped at runtime",
+				Request:        w.NewRequest(http.MethodGet, "/", nil),
+				AssertResponse: func(*http.Response, error) {},
+				Skip:           func() bool { return true },
+			},
+		}
+		reporter.Listen(t, tests)
+		w.Test(t, tests)
+	})
+
+	report, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatalf("Error reading report: %v", err)
+	}
+	for _, expected := range []string{
+		`<testsuite name="TestJUnitReporter/suite" tests="4" failures="0" skipped="2"`,
+		`<testcase name="passing" classname="TestJUnitReporter/suite"`,
+		`<testcase name="skipped" classname="TestJUnitReporter/suite" time="0.000">`,
+		`<testcase name="skipped at runtime" classname="TestJUnitReporter/suite" time="0.000">`,
+	} {
+		if !strings.Contains(string(report), expected) {
+			t.Fatalf("Report does not contain %s\nReport: %s", expected, report)
+		}
+	}
+}
+
+// TestJUnitReporterFailures runs failing tests in a child process, as they would fail this test otherwise.
+func TestJUnitReporterFailures(t *testing.T) {
+	if path := os.Getenv("WISENT_JUNIT_REPORT"); path != "" {
+		runFailingSuite(t, path)
+		return
+	}
+
+	path := filepath.Join(t.TempDir(), "report.xml")
+	cmd := exec.Command(os.Args[0], "-test.run=^TestJUnitReporterFailures$")
+	cmd.Env = append(os.Environ(), "WISENT_JUNIT_REPORT="+path)
+	if out, err := cmd.CombinedOutput(); err == nil {
+		t.Fatalf("Expected the failing suite to fail\nOutput: %s", out)
+	}
+
+	report, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatalf("Error reading report: %v", err)
+	}
+	for _, expected := range []string{
+		`tests="3" failures="2" skipped="0"`,
+		`<failure message="Body mismatch&#xA;Expected: first&#xA;Actual: ok"></failure>`,
+		`<failure message="Body mismatch&#xA;Expected: second&#xA;Actual: ok"></failure>`,
+	} {
+		if !strings.Contains(string(report), expected) {
+			t.Fatalf("Report does not contain %s\nReport: %s", expected, report)
+		}
+	}
+	if strings.Count(string(report), "<failure") != 2 {
+		t.Fatalf("Expected only 2 failures\nReport: %s", report)
+	}
+}
+
+func runFailingSuite(t *testing.T, path string) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		w.Write([]byte("ok"))
+	}))
+	defer server.Close()
+
+	w := wisent.New(server.URL)
+	reporter := wisent.NewJUnitReporter(path)
+	tests := []wisent.Test{}
+	for _, body := range []string{"first", "ok", "second"} {
+		tests = append(tests, wisent.Test{
+			Name:    body,
+			Request: w.NewRequest(http.MethodGet, "/", nil),
+			AssertResponse: func(resp *http.Response, err error) {
+				w.AssertResponseError(t, err)
+				w.AssertResponseBody(t, body, resp)
+			},
+		})
+	}
+	reporter.Listen(t, tests)
+	w.Test(t, tests)
+}
@@ -0,0 +1,123 @@
+package wisent
+
+import (
+	"bufio"
+	"compress/flate"
+	"compress/gzip"
+	"compress/zlib"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"sync"
+)
+
+// decompressionTransport is an http.RoundTripper that decompresses response bodies
+// according to their Content-Encoding header.
+type decompressionTransport struct {
+	next http.RoundTripper
+}
+
+func (t *decompressionTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	resp, err := t.next.RoundTrip(req)
+	if err != nil {
+		return resp, err
+	}
+
+	var body io.ReadCloser
+	switch strings.ToLower(strings.TrimSpace(resp.Header.Get("Content-Encoding"))) {
+	case "gzip", "x-gzip":
+		gr, err := gzip.NewReader(resp.Body)
+		if err != nil {
+			resp.Body.Close()
+			return nil, fmt.Errorf("creating gzip reader: %w", err)
+		}
+		body = gr
+	case "deflate":
+		body, err = newDeflateReader(resp.Body)
+		if err != nil {
+			resp.Body.Close()
+			return nil, fmt.Errorf("creating deflate reader: %w", err)
+		}
+	default:
+		return resp, nil
+	}
+
+	resp.Body = &decompressedBody{ReadCloser: body, raw: resp.Body}
+	resp.Header.Del("Content-Encoding")
+	resp.Header.Del("Content-Length")
+	resp.ContentLength = -1
+	resp.Uncompressed = true
+	return resp, nil
+}
+
+// newDeflateReader handles both zlib-wrapped (as mandated by the RFC) and raw deflate streams,
+// since servers are not consistent about which one they send.
+func newDeflateReader(r io.Reader) (io.ReadCloser, error) {
+	br := bufio.NewReader(r)
+	header, err := br.Peek(2)
+	if err != nil && err != io.EOF {
+		return nil, err
+	}
+	if len(header) == 2 && header[0]&0x0f == 8 && (uint16(header[0])<<8|uint16(header[1]))%31 == 0 {
+		return zlib.NewReader(br)
+	}
+	return flate.NewReader(br), nil
+}
+
+// decompressedBody closes both the decompressing reader and the original body.
+type decompressedBody struct {
+	io.ReadCloser
+	raw io.ReadCloser
+}
+
+func (b *decompressedBody) Close() error {
+	err := b.ReadCloser.Close()
+	if rawErr := b.raw.Close(); err == nil {
+		err = rawErr
+	}
+	return err
+}
+
+// limitedBody stops reading a response body after a number of bytes.
+// If the body is longer, onTruncate is called once the limit is reached.
+type limitedBody struct {
+	io.ReadCloser
+	remaining  int64
+	onTruncate func()
+	truncated  bool
+}
+
+func (b *limitedBody) Read(p []byte) (int, error) {
+	if b.remaining <= 0 {
+		if !b.truncated {
+			b.truncated = true
+			if n, _ := b.ReadCloser.Read(make([]byte, 1)); n > 0 {
+				b.onTruncate()
+			}
+		}
+		return 0, io.EOF
+	}
+	if int64(len(p)) > b.remaining {
+		p = p[:b.remaining]
+	}
+	n, err := b.ReadCloser.Read(p)
+	b.remaining -= int64(n)
+	return n, err
+}
+
+// protocolCheckTransport calls onMismatch once, for the first response with a different major protocol version.
+type protocolCheckTransport struct {
+	next       http.RoundTripper
+	protoMajor int
+	onMismatch func(proto string)
+	once       sync.Once
+}
+
+func (t *protocolCheckTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	resp, err := t.next.RoundTrip(req)
+	if err == nil && resp.ProtoMajor != t.protoMajor {
+		t.once.Do(func() { t.onMismatch(resp.Proto) })
+	}
+	return resp, err
+}
@@ -2,7 +2,10 @@ package wisent
 
 import (
 	"context"
+	"io"
 	"net/http"
+	"testing"
+	"time"
 )
 
 type (
@@ -15,8 +18,17 @@ type (
 	// RequestWrapper is a function that wraps an HTTP request.
 	// It takes a pointer to a Wisent instance and an *http.Request as input and returns an *http.Response and an error.
 	RequestWrapper func(w *Wisent, req *http.Request) (*http.Response, error)
+	// RetryCondition is a function that decides whether a request should be retried.
+	// It takes the response and the error of the last attempt and returns true if the request should be retried.
+	RetryCondition func(resp *http.Response, err error) bool
 )
 
+// MultipartFile is a file with an explicit filename, for use with NewMultipartRequest.
+type MultipartFile struct {
+	io.Reader
+	Filename string
+}
+
 // Test represents a test case for a Wisent instance.
 // It includes a name, an HTTP request, optional pre and post request functions, and a function to assert the response.
 type Test struct {
@@ -25,6 +37,69 @@ type Test struct {
 	PreRequest     func(req *http.Request)
 	AssertResponse func(resp *http.Response, err error)
 	PostRequest    func(resp *http.Response)
+	// Timeout limits the duration of the request, on top of the HTTP client timeout.
+	// If empty, only the HTTP client timeout applies.
+	Timeout time.Duration
+	// Tags are used to select tests with WithTags and WithSkipTags options.
+	Tags []string
+	// Setup is run before PreRequest. A returned error fails the test.
+	// It can be used to prepare data, e.g. to create resources the test depends on.
+	Setup func(w *Wisent) error
+	// StateTransform is run after the assertions in a sequential test.
+	// It can be used to store data from the response, e.g. an ID of a created resource, for the following tests.
+	StateTransform func(state State, resp *http.Response)
+	// Retry is the number of times the request is re-issued if it qualifies for a retry, as decided by RetryIf,
+	// or if wisent assertions made in AssertResponse fail, e.g. while eventually consistent data is not there yet.
+	// Only the last attempt is reported, with the number of attempts. If empty, the request is performed once.
+	// Assertions of parallel tests, and failures reported directly on the testing.T, are not retried.
+	Retry int
+	// RetryDelay is the time to wait between attempts.
+	RetryDelay time.Duration
+	// RetryIf decides whether the request should be retried before its response is asserted.
+	// Responses of attempts retried this way are not passed to PostRequest and AssertResponse.
+	// If empty, the request is retried when it fails with an error.
+	RetryIf RetryCondition
+	// Parallel runs the test in parallel with other parallel tests, using t.Parallel.
+	// The app is still started once and shared by all tests.
+	// It is ignored by RunSuite and RunSequential, which always run tests in order.
+	Parallel bool
+	// Cleanup is registered with t.Cleanup of the subtest, so it runs after the test completes, even if it failed.
+	// It can be used to delete resources created by the test.
+	Cleanup func()
+	// Skip is checked at the start of the test, which is skipped if it returns true,
+	// e.g. to run the test only against a specific environment or with a feature flag enabled.
+	Skip func() bool
+	// SkipReason is logged when the test is skipped by Skip.
+	SkipReason string
+	// AssertResponseLatency is run after AssertResponse, with the time it took to perform the request, including retries.
+	// It can be used to assert latency, e.g. with AssertResponseTime.
+	AssertResponseLatency func(resp *http.Response, err error, latency time.Duration)
+
+	// report is called by JUnitReporter once the subtest completes, with the subtest, its duration
+	// and the messages of its failures.
+	report func(t *testing.T, elapsed time.Duration, failures []string)
+}
+
+// State holds data shared between the tests of a SequentialTest.
+type State map[string]any
+
+// SequentialTest represents a chain of dependent tests, e.g. a create, read, update and delete flow.
+// Tests read the state in their PreRequest functions by closing over it,
+// and update it in their StateTransform functions.
+type SequentialTest struct {
+	Tests []Test
+	// State is shared between the tests. If nil, an empty state is used.
+	State State
+}
+
+// TestSuite represents a group of tests that share the app lifecycle and setup.
+// State between tests can be shared by closing over common variables in the test functions.
+type TestSuite struct {
+	// Setup is run once, before the first test.
+	Setup func(w *Wisent) error
+	// Teardown is run once, after the last test, even if some tests failed.
+	Teardown func(w *Wisent) error
+	Tests    []Test
 }
 
 // Benchmark represents a benchmark test for a Wisent instance.
@@ -35,4 +110,24 @@ type Benchmark struct {
 	PreRequest     func(req *http.Request)
 	AssertResponse func(resp *http.Response, err error)
 	PostRequest    func(resp *http.Response)
+	// Concurrency is the total number of goroutines used by BenchmarkParallel.
+	// It is rounded down to a multiple of GOMAXPROCS (but not below it), as required by testing.B.SetParallelism.
+	// If empty, the testing default of GOMAXPROCS goroutines is used.
+	Concurrency int
+	// Duration is how long BenchmarkDuration keeps sending requests, regardless of b.N.
+	Duration time.Duration
+	// WarmupIterations is the number of requests sent before the timer starts, e.g. to fill connection pools.
+	// They are not recorded in the result or other metrics, and their responses are not asserted.
+	// Against an app not started with StartFunc, warmup runs only in the first calibration round of b.N.
+	WarmupIterations int
+	// Bytes is the number of bytes processed by a single request, reported with testing.B.SetBytes.
+	// If empty, throughput in bytes is not reported.
+	Bytes int64
+}
+
+// WorkerPool schedules functions for execution, e.g. on a fixed number of goroutines.
+// It is used by BenchmarkParallelWithPool to give callers control over concurrency.
+type WorkerPool interface {
+	// Submit schedules the function for execution. It may block until a worker is available.
+	Submit(func()) error
 }
@@ -4,13 +4,22 @@ import (
 	"context"
 	"errors"
 	"fmt"
+	"io"
+	"math/rand/v2"
 	"net"
 	"net/http"
+	"sync"
 	"time"
 )
 
-// ErrHealthCheckTimeout is returned when the health check fails to complete within the specified timeout period.
-var ErrHealthCheckTimeout = errors.New("health check timeout reached")
+var (
+	// ErrHealthCheckTimeout is returned when the health check fails to complete within the specified timeout period.
+	ErrHealthCheckTimeout = errors.New("health check timeout reached")
+	// ErrBodyNotRewindable is returned when a request has to be retried, but its body cannot be read again.
+	ErrBodyNotRewindable = errors.New("request body cannot be rewound")
+	// ErrCircuitOpen is returned by CircuitBreakerWrapper when requests are rejected without being sent.
+	ErrCircuitOpen = errors.New("circuit breaker is open")
+)
 
 // DefaultHttpClient returns a pre-configured http.Client with specific timeout and connection settings.
 // This client is suitable for making HTTP requests with consistent timeout behavior and connection reuse.
@@ -44,7 +53,7 @@ func HealthCheckReadinessProbe(url string, timeout time.Duration, sleep time.Dur
 	return func(ctx context.Context, w *Wisent) error {
 		startTime := time.Now()
 		for {
-			w.Logger.Info("Checking readiness")
+			w.Logger.Info("Checking readiness", "elapsed", time.Since(startTime))
 			req, err := http.NewRequestWithContext(
 				ctx,
 				http.MethodGet,
@@ -87,24 +96,280 @@ func HealthCheckReadinessProbe(url string, timeout time.Duration, sleep time.Dur
 	}
 }
 
+// TCPReadinessProbe creates a ReadinessProbe function that waits for a TCP port to accept connections.
+//
+// It dials the specified address (in "host:port" format) at regular intervals until either:
+// - A connection is established
+// - The context is cancelled
+// - The specified timeout duration is reached
+//
+// The returned function will return nil if the connection succeeds, or an error
+// if the probe fails due to timeout or context cancellation.
+func TCPReadinessProbe(addr string, timeout time.Duration, sleep time.Duration) ReadinessProbe {
+	return func(ctx context.Context, w *Wisent) error {
+		startTime := time.Now()
+		for {
+			w.Logger.Info("Checking readiness", "addr", addr, "elapsed", time.Since(startTime))
+			conn, err := net.DialTimeout("tcp", addr, sleep)
+			if err == nil {
+				conn.Close()
+				return nil
+			}
+
+			select {
+			case <-ctx.Done():
+				return ctx.Err()
+			default:
+				if time.Since(startTime) >= timeout {
+					return ErrHealthCheckTimeout
+				}
+				time.Sleep(sleep)
+			}
+		}
+	}
+}
+
+// CompositeAndReadinessProbe creates a ReadinessProbe function that runs all the provided probes concurrently.
+//
+// It returns nil once all probes succeed. If any probe fails, the remaining ones are cancelled
+// and the first error is returned.
+func CompositeAndReadinessProbe(probes ...ReadinessProbe) ReadinessProbe {
+	return func(ctx context.Context, w *Wisent) error {
+		ctx, cancel := context.WithCancel(ctx)
+		defer cancel()
+
+		var (
+			wg       sync.WaitGroup
+			once     sync.Once
+			firstErr error
+		)
+		for _, probe := range probes {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				if err := probe(ctx, w); err != nil {
+					once.Do(func() {
+						firstErr = err
+						cancel()
+					})
+				}
+			}()
+		}
+		wg.Wait()
+		return firstErr
+	}
+}
+
+// CompositeOrReadinessProbe creates a ReadinessProbe function that runs all the provided probes concurrently.
+//
+// It returns nil as soon as any probe succeeds, cancelling the remaining ones.
+// If all probes fail, their errors are returned joined.
+func CompositeOrReadinessProbe(probes ...ReadinessProbe) ReadinessProbe {
+	return func(ctx context.Context, w *Wisent) error {
+		ctx, cancel := context.WithCancel(ctx)
+		defer cancel()
+
+		results := make(chan error, len(probes))
+		for _, probe := range probes {
+			go func() { results <- probe(ctx, w) }()
+		}
+
+		var errs []error
+		for range probes {
+			err := <-results
+			if err == nil {
+				return nil
+			}
+			errs = append(errs, err)
+		}
+		return errors.Join(errs...)
+	}
+}
+
 // SimpleRetry creates a RequestWrapper that implements a simple retry mechanism for HTTP requests.
 //
 // It attempts to perform the request up to 'maxAttempts' times, with an increasing delay between each attempt.
 // The delay starts at 'baseSleep' and increases linearly with each retry.
+// Values of 'maxAttempts' lower than 1 are treated as 1.
 //
 // The wrapper logs each attempt and any errors encountered. If all attempts fail, it returns the last error encountered.
 func SimpleRetry(maxAttempts int, baseSleep time.Duration) RequestWrapper {
+	maxAttempts = max(maxAttempts, 1)
 	return func(w *Wisent, req *http.Request) (resp *http.Response, err error) {
-		for i := range 5 {
+		for i := range maxAttempts {
+			if i > 0 {
+				if err := rewindBody(req); err != nil {
+					return nil, err
+				}
+			}
 			w.Logger.Info("Performing the request")
 			resp, err = w.HttpClient.Do(req)
-			if err != nil {
-				w.Logger.Warn("Error performing request, sleeping", "err", err, "sleep", time.Duration(i*int(baseSleep)))
-				time.Sleep(time.Duration(i * int(baseSleep)))
-				continue
+			if err == nil {
+				return resp, err
 			}
-			return resp, err
+			if i == maxAttempts-1 {
+				break
+			}
+			sleep := time.Duration(i+1) * baseSleep
+			w.Logger.Warn("Error performing request, sleeping", "err", err, "sleep", sleep)
+			time.Sleep(sleep)
 		}
 		return nil, err
 	}
 }
+
+// ExponentialBackoffRetry creates a RequestWrapper that retries HTTP requests with exponential back-off.
+//
+// It attempts to perform the request up to 'maxAttempts' times. The delay starts at 'baseDelay',
+// doubles with each retry and is capped at 'maxDelay'. A random jitter of ±10% is added to every delay,
+// so that parallel benchmark goroutines do not retry in lockstep.
+//
+// By default, only requests that failed with an error are retried. If retry conditions are provided,
+// the request is retried when any of them returns true, e.g. to retry on 503 or 429 status codes.
+//
+// The request body is rewound before each retry. If that is not possible, ErrBodyNotRewindable is returned.
+func ExponentialBackoffRetry(maxAttempts int, baseDelay time.Duration, maxDelay time.Duration, conditions ...RetryCondition) RequestWrapper {
+	maxAttempts = max(maxAttempts, 1)
+	if len(conditions) == 0 {
+		conditions = []RetryCondition{func(_ *http.Response, err error) bool { return err != nil }}
+	}
+	return func(w *Wisent, req *http.Request) (resp *http.Response, err error) {
+		delay := min(baseDelay, maxDelay)
+		for i := range maxAttempts {
+			if i > 0 {
+				if err := rewindBody(req); err != nil {
+					return nil, err
+				}
+			}
+			w.Logger.Info("Performing the request", "attempt", i+1)
+			resp, err = w.HttpClient.Do(req)
+			if i == maxAttempts-1 || !shouldRetry(conditions, resp, err) {
+				return resp, err
+			}
+			if resp != nil {
+				resp.Body.Close()
+			}
+
+			sleep := time.Duration(float64(delay) * (0.9 + 0.2*rand.Float64()))
+			w.Logger.Warn("Retrying request, sleeping", "err", err, "sleep", sleep)
+			select {
+			case <-req.Context().Done():
+				return nil, req.Context().Err()
+			case <-time.After(sleep):
+			}
+			delay = min(delay*2, maxDelay)
+		}
+		return resp, err
+	}
+}
+
+func shouldRetry(conditions []RetryCondition, resp *http.Response, err error) bool {
+	for _, condition := range conditions {
+		if condition(resp, err) {
+			return true
+		}
+	}
+	return false
+}
+
+// rewindBody prepares the request body to be sent again.
+func rewindBody(req *http.Request) error {
+	if req.Body == nil || req.Body == http.NoBody {
+		return nil
+	}
+	if req.GetBody != nil {
+		body, err := req.GetBody()
+		if err != nil {
+			return fmt.Errorf("getting request body: %w", err)
+		}
+		req.Body = body
+		return nil
+	}
+	if seeker, ok := req.Body.(io.Seeker); ok {
+		if _, err := seeker.Seek(0, io.SeekStart); err != nil {
+			return fmt.Errorf("seeking request body: %w", err)
+		}
+		return nil
+	}
+	return ErrBodyNotRewindable
+}
+
+// CircuitBreakerWrapper creates a RequestWrapper that stops sending requests after repeated failures.
+//
+// After 'threshold' consecutive request errors, the circuit opens and every request fails immediately
+// with ErrCircuitOpen, without making a network call. After 'resetAfter', the circuit closes again.
+// This lets benchmarks degrade gracefully instead of piling up requests against a failing system.
+//
+// The wrapper is safe for concurrent use, e.g. in BenchmarkParallel.
+func CircuitBreakerWrapper(threshold int, resetAfter time.Duration) RequestWrapper {
+	var (
+		mu       sync.Mutex
+		failures int
+		openedAt time.Time
+	)
+	return func(w *Wisent, req *http.Request) (*http.Response, error) {
+		mu.Lock()
+		if !openedAt.IsZero() {
+			if time.Since(openedAt) < resetAfter {
+				mu.Unlock()
+				return nil, ErrCircuitOpen
+			}
+			w.Logger.Info("Closing the circuit")
+			openedAt, failures = time.Time{}, 0
+		}
+		mu.Unlock()
+
+		w.Logger.Info("Performing the request")
+		resp, err := w.HttpClient.Do(req)
+
+		mu.Lock()
+		defer mu.Unlock()
+		if err == nil {
+			failures = 0
+			return resp, nil
+		}
+		failures++
+		if failures >= threshold && openedAt.IsZero() {
+			w.Logger.Warn("Opening the circuit", "failures", failures, "err", err)
+			openedAt = time.Now()
+		}
+		return nil, err
+	}
+}
+
+// RateLimitedWrapper creates a RequestWrapper that sends at most 'rps' requests per second,
+// blocking each request until its turn comes, or until its context is done.
+// The limit is shared by all requests made through the wrapper, e.g. by all goroutines in BenchmarkParallel,
+// and requests are spaced evenly, without bursts. If 'rps' is not positive, requests are not limited.
+func RateLimitedWrapper(rps int) RequestWrapper {
+	var (
+		mu   sync.Mutex
+		next time.Time
+	)
+	return func(w *Wisent, req *http.Request) (*http.Response, error) {
+		if rps > 0 {
+			mu.Lock()
+			now := time.Now()
+			if next.Before(now) {
+				next = now
+			}
+			wait := next.Sub(now)
+			next = next.Add(time.Second / time.Duration(rps))
+			mu.Unlock()
+
+			if wait > 0 {
+				w.Logger.Debug("Waiting for the rate limiter", "wait", wait)
+				timer := time.NewTimer(wait)
+				select {
+				case <-timer.C:
+				case <-req.Context().Done():
+					timer.Stop()
+					return nil, req.Context().Err()
+				}
+			}
+		}
+
+		w.Logger.Info("Performing the request")
+		return w.HttpClient.Do(req)
+	}
+}
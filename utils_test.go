@@ -0,0 +1,160 @@
+package wisent_test
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net/http"
+	"slices"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/ttyobiwan/wisent"
+)
+
+type roundTripFunc func(req *http.Request) (*http.Response, error)
+
+func (f roundTripFunc) RoundTrip(req *http.Request) (*http.Response, error) { return f(req) }
+
+func TestSimpleRetry(t *testing.T) {
+	tests := []struct {
+		name        string
+		maxAttempts int
+		want        int
+	}{
+		{name: "stops after max attempts", maxAttempts: 3, want: 3},
+		{name: "non-positive attempts are treated as one", maxAttempts: 0, want: 1},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			attempts := 0
+			errFailed := errors.New("failed")
+			client := &http.Client{Transport: roundTripFunc(func(*http.Request) (*http.Response, error) {
+				attempts++
+				return nil, errFailed
+			})}
+			w := wisent.New("http://127.0.0.1", wisent.WithHttpClient(client))
+
+			_, err := wisent.SimpleRetry(tt.maxAttempts, 0)(w, w.NewRequest(http.MethodGet, "/", nil))
+
+			if !errors.Is(err, errFailed) {
+				t.Fatalf("Incorrect error, got: %v, want: %v", err, errFailed)
+			}
+			if attempts != tt.want {
+				t.Fatalf("Incorrect number of attempts, got: %v, want: %v", attempts, tt.want)
+			}
+		})
+	}
+}
+
+func TestExponentialBackoffRetry(t *testing.T) {
+	var bodies []string
+	client := &http.Client{Transport: roundTripFunc(func(req *http.Request) (*http.Response, error) {
+		body, _ := io.ReadAll(req.Body)
+		bodies = append(bodies, string(body))
+		status := http.StatusServiceUnavailable
+		if len(bodies) == 3 {
+			status = http.StatusOK
+		}
+		return &http.Response{StatusCode: status, Body: http.NoBody}, nil
+	})}
+	w := wisent.New("http://127.0.0.1", wisent.WithHttpClient(client))
+	retryUnavailable := func(resp *http.Response, err error) bool {
+		return err == nil && resp.StatusCode == http.StatusServiceUnavailable
+	}
+
+	resp, err := wisent.ExponentialBackoffRetry(5, time.Millisecond, 2*time.Millisecond, retryUnavailable)(
+		w, w.NewRequest(http.MethodPost, "/", strings.NewReader("payload")),
+	)
+
+	w.AssertResponseError(t, err)
+	w.AssertResponseStatusCode(t, http.StatusOK, resp)
+	if want := []string{"payload", "payload", "payload"}; !slices.Equal(bodies, want) {
+		t.Fatalf("Incorrect request bodies, got: %q, want: %q", bodies, want)
+	}
+}
+
+func TestExponentialBackoffRetryBaseDelayAboveMax(t *testing.T) {
+	attempts := 0
+	client := &http.Client{Transport: roundTripFunc(func(*http.Request) (*http.Response, error) {
+		attempts++
+		return nil, errors.New("connection refused")
+	})}
+	w := wisent.New("http://127.0.0.1", wisent.WithHttpClient(client))
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+
+	_, err := wisent.ExponentialBackoffRetry(3, time.Hour, time.Millisecond)(
+		w, w.NewRequest(http.MethodGet, "/", nil).WithContext(ctx),
+	)
+
+	if errors.Is(err, context.DeadlineExceeded) {
+		t.Fatalf("Delay not capped at max delay, got: %v", err)
+	}
+	if attempts != 3 {
+		t.Fatalf("Incorrect number of attempts, got: %v, want: 3", attempts)
+	}
+}
+
+func TestCompositeReadinessProbes(t *testing.T) {
+	errNotReady := errors.New("not ready")
+	ready := func(context.Context, *wisent.Wisent) error { return nil }
+	notReady := func(context.Context, *wisent.Wisent) error { return errNotReady }
+	blocking := func(ctx context.Context, _ *wisent.Wisent) error {
+		<-ctx.Done()
+		return ctx.Err()
+	}
+	w := wisent.New("http://127.0.0.1")
+
+	if err := wisent.CompositeAndReadinessProbe(ready, ready)(context.Background(), w); err != nil {
+		t.Fatalf("Incorrect error of and probe, got: %v, want: nil", err)
+	}
+	if err := wisent.CompositeAndReadinessProbe(blocking, notReady)(context.Background(), w); !errors.Is(err, errNotReady) {
+		t.Fatalf("Incorrect error of and probe, got: %v, want: %v", err, errNotReady)
+	}
+	if err := wisent.CompositeOrReadinessProbe(blocking, ready)(context.Background(), w); err != nil {
+		t.Fatalf("Incorrect error of or probe, got: %v, want: nil", err)
+	}
+	if err := wisent.CompositeOrReadinessProbe(notReady, notReady)(context.Background(), w); !errors.Is(err, errNotReady) {
+		t.Fatalf("Incorrect error of or probe, got: %v, want: %v", err, errNotReady)
+	}
+}
+
+func TestCircuitBreakerWrapper(t *testing.T) {
+	attempts := 0
+	errFailed := errors.New("failed")
+	client := &http.Client{Transport: roundTripFunc(func(*http.Request) (*http.Response, error) {
+		attempts++
+		return nil, errFailed
+	})}
+	w := wisent.New("http://127.0.0.1", wisent.WithHttpClient(client))
+	wrapper := wisent.CircuitBreakerWrapper(2, time.Hour)
+
+	for _, want := range []error{errFailed, errFailed, wisent.ErrCircuitOpen, wisent.ErrCircuitOpen} {
+		if _, err := wrapper(w, w.NewRequest(http.MethodGet, "/", nil)); !errors.Is(err, want) {
+			t.Fatalf("Incorrect error, got: %v, want: %v", err, want)
+		}
+	}
+	if attempts != 2 {
+		t.Fatalf("Incorrect number of attempts, got: %v, want: %v", attempts, 2)
+	}
+}
+
+func TestRateLimitedWrapper(t *testing.T) {
+	client := &http.Client{Transport: roundTripFunc(func(*http.Request) (*http.Response, error) {
+		return &http.Response{StatusCode: http.StatusOK, Body: http.NoBody}, nil
+	})}
+	w := wisent.New("http://127.0.0.1", wisent.WithHttpClient(client))
+	wrapper := wisent.RateLimitedWrapper(100)
+
+	start := time.Now()
+	for i := 0; i < 5; i++ {
+		if _, err := wrapper(w, w.NewRequest(http.MethodGet, "/", nil)); err != nil {
+			t.Fatalf("Error performing request: %v", err)
+		}
+	}
+	if elapsed := time.Since(start); elapsed < 40*time.Millisecond {
+		t.Fatalf("Incorrect elapsed time, got: %v, want at least: %v", elapsed, 40*time.Millisecond)
+	}
+}
@@ -1,34 +1,27 @@
 package wisent
 
 import (
+	"bytes"
 	"context"
+	"encoding/json"
+	"errors"
 	"fmt"
 	"io"
 	"log/slog"
+	"math/rand/v2"
+	"mime/multipart"
 	"net/http"
+	"net/http/cookiejar"
+	"net/url"
+	"runtime"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
 	"testing"
+	"time"
 )
 
-type WisentOpt func(w *Wisent)
-
-func WithStartFunc(start StartFunc) WisentOpt { return func(w *Wisent) { w.Start = start } }
-
-func WithReadinessProbe(rp ReadinessProbe) WisentOpt {
-	return func(w *Wisent) { w.ReadinessProbe = rp }
-}
-
-func WithHttpClient(client *http.Client) WisentOpt {
-	return func(w *Wisent) { w.HttpClient = client }
-}
-
-func WithRequestWrapper(rw RequestWrapper) WisentOpt {
-	return func(w *Wisent) { w.RequestWrapper = rw }
-}
-
-func WithLogger(logger *slog.Logger) WisentOpt {
-	return func(w *Wisent) { w.Logger = logger }
-}
-
 // Wisent represents a configuration for running API tests and benchmarks.
 // It provides a flexible way to set up and execute HTTP requests against a target API.
 type Wisent struct {
@@ -53,24 +46,130 @@ type Wisent struct {
 	// Logger is used for logging test progress and information.
 	// If not provided, a default logger writing to io.Discard will be used.
 	Logger *slog.Logger
+
+	// optErrs collects errors of options that validate their arguments.
+	optErrs []error
+	// transportOpts modify the transport of the default HTTP client.
+	transportOpts []func(*http.Transport)
+	// transportWrappers wrap the transport of the HTTP client, including a custom one.
+	transportWrappers   []func(http.RoundTripper) http.RoundTripper
+	decompressResponses bool
+	cookieJar           http.CookieJar
+	bodySizeStats       *BodySizeStats
+	samplingRate        float64
+	maxResponseBodySize int64
+	defaultHeaders      http.Header
+	userAgent           string
+	benchmarkName       string
+	auditLog            *auditLog
+	onDuplicate         func(req *http.Request)
+	sentRequests        *sync.Map
+	assertionRouter     *assertionRouter
+	warmedUp            *sync.Map
+	baseCtx             context.Context
+	deadline            time.Time
+	failFastAfter       int
+	successStatus       *[2]int
+	readinessProbeOnce  bool
+	readinessProbeDone  *sync.Once
+	cloneClient         bool
+	followRedirects     *bool
+	contentLengthFix    bool
+	responseDecoder     func(io.ReadCloser) (io.ReadCloser, error)
+	openAPISpec         *openAPISpec
+	harLog              *harLog
+	benchmarkBarrier    bool
+	maxTestDuration     time.Duration
+	http2               bool
+	tags                []string
+	skipTags            []string
+	baseQueryParams     url.Values
 }
 
 // New creates and returns a new Wisent instance with the specified base URL and options.
 // It applies the provided options to customize the Wisent instance.
 func New(baseUrl string, options ...WisentOpt) *Wisent {
-	w := &Wisent{BaseURL: baseUrl}
+	w := &Wisent{BaseURL: baseUrl, sentRequests: &sync.Map{}, readinessProbeDone: &sync.Once{}, assertionRouter: &assertionRouter{}, warmedUp: &sync.Map{}}
 	for _, opt := range options {
 		opt(w)
 	}
-	if w.HttpClient == nil {
+	if err := errors.Join(w.optErrs...); err != nil {
+		panic(fmt.Errorf("applying options: %v", err))
+	}
+	customClient := w.HttpClient != nil
+	if !customClient {
 		w.HttpClient = DefaultHttpClient()
 	}
 	if w.Logger == nil {
 		w.Logger = slog.New(slog.NewTextHandler(io.Discard, nil))
 	}
+	if w.benchmarkName != "" {
+		w.Logger = w.Logger.With("benchmark", w.benchmarkName)
+	}
+	if w.userAgent != "" {
+		if ua := w.defaultHeaders.Get("User-Agent"); ua != "" {
+			w.Logger.Warn("User-Agent from default headers is overridden", "default", ua, "override", w.userAgent)
+		}
+		if w.defaultHeaders == nil {
+			w.defaultHeaders = http.Header{}
+		}
+		w.defaultHeaders.Set("User-Agent", w.userAgent)
+	}
+	if len(w.transportOpts) > 0 {
+		if customClient {
+			w.Logger.Warn("Transport options are ignored when a custom HTTP client is provided")
+		} else {
+			transport := w.HttpClient.Transport.(*http.Transport)
+			for _, opt := range w.transportOpts {
+				opt(transport)
+			}
+		}
+	}
+	if w.http2 && !customClient {
+		if strings.HasPrefix(w.BaseURL, "https://") {
+			w.wrapTransport(func(next http.RoundTripper) http.RoundTripper {
+				return &protocolCheckTransport{next: next, protoMajor: 2, onMismatch: func(proto string) {
+					w.Logger.Warn("HTTP/2 was not negotiated", "proto", proto)
+				}}
+			})
+		} else {
+			w.Logger.Warn("HTTP/2 is only negotiated over HTTPS, requests will use HTTP/1.1", "base_url", w.BaseURL)
+		}
+	}
+	if w.followRedirects != nil {
+		if customClient {
+			w.Logger.Warn("Redirect policy is ignored when a custom HTTP client is provided")
+		} else if !*w.followRedirects {
+			w.HttpClient.CheckRedirect = func(*http.Request, []*http.Request) error { return http.ErrUseLastResponse }
+		}
+	}
+	if w.decompressResponses {
+		w.wrapTransport(func(next http.RoundTripper) http.RoundTripper {
+			return &decompressionTransport{next: next}
+		})
+	}
+	for _, wrap := range w.transportWrappers {
+		w.wrapTransport(wrap)
+	}
+	if w.cookieJar != nil {
+		client := *w.HttpClient
+		client.Jar = w.cookieJar
+		w.HttpClient = &client
+	}
 	return w
 }
 
+// wrapTransport wraps the transport of the HTTP client.
+// The client is copied first, so that a client supplied by the caller is never modified.
+func (w *Wisent) wrapTransport(wrap func(http.RoundTripper) http.RoundTripper) {
+	client := *w.HttpClient
+	if client.Transport == nil {
+		client.Transport = http.DefaultTransport
+	}
+	client.Transport = wrap(client.Transport)
+	w.HttpClient = &client
+}
+
 // NewRequest is a helper method that allows building requests without checking for errors.
 // This is handy in tests, where we (usually) know what we are doing.
 func (w *Wisent) NewRequest(method string, url string, body io.Reader) *http.Request {
@@ -78,124 +177,745 @@ func (w *Wisent) NewRequest(method string, url string, body io.Reader) *http.Req
 	if err != nil {
 		panic(fmt.Errorf("creating request: %v", err))
 	}
+	w.applyBaseQueryParams(req)
+	return req
+}
+
+// NewRequestWithHeaders is a helper method that builds a request like NewRequest, and sets the provided headers on it.
+func (w *Wisent) NewRequestWithHeaders(method string, url string, body io.Reader, headers http.Header) *http.Request {
+	req := w.NewRequest(method, url, body)
+	for key, values := range headers {
+		req.Header[http.CanonicalHeaderKey(key)] = slices.Clone(values)
+	}
+	return req
+}
+
+// NewJSONRequest is a helper method that builds a request with the body encoded as JSON.
+// It sets the Content-Type header and panics if the body cannot be encoded.
+// A nil body results in an empty JSON object.
+func (w *Wisent) NewJSONRequest(method string, url string, body any) *http.Request {
+	data := []byte("{}")
+	if body != nil {
+		var err error
+		data, err = json.Marshal(body)
+		if err != nil {
+			panic(fmt.Errorf("encoding request body: %v", err))
+		}
+	}
+	req := w.NewRequest(method, url, bytes.NewReader(data))
+	req.Header.Set("Content-Type", "application/json")
+	return req
+}
+
+// NewFormRequest is a helper method that builds a request with the values encoded as form body.
+// It sets the Content-Type header to application/x-www-form-urlencoded. Nil values result in an empty body.
+func (w *Wisent) NewFormRequest(method string, url string, values url.Values) *http.Request {
+	req := w.NewRequest(method, url, strings.NewReader(values.Encode()))
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	return req
+}
+
+// NewMultipartRequest is a helper method that builds a multipart/form-data request, e.g. for file uploads.
+// Files are keyed by field name, which is also used as the filename, unless the reader is a MultipartFile
+// or a pointer to one.
+// It sets the Content-Type header with the boundary and panics if the body cannot be built.
+func (w *Wisent) NewMultipartRequest(method string, url string, fields map[string]string, files map[string]io.Reader) *http.Request {
+	var body bytes.Buffer
+	mw := multipart.NewWriter(&body)
+
+	for _, name := range sortedKeys(fields) {
+		if err := mw.WriteField(name, fields[name]); err != nil {
+			panic(fmt.Errorf("writing field %q: %v", name, err))
+		}
+	}
+	for _, name := range sortedKeys(files) {
+		file, filename := files[name], name
+		switch mf := file.(type) {
+		case MultipartFile:
+			filename = mf.Filename
+		case *MultipartFile:
+			filename = mf.Filename
+		}
+		part, err := mw.CreateFormFile(name, filename)
+		if err != nil {
+			panic(fmt.Errorf("creating file %q: %v", name, err))
+		}
+		if _, err := io.Copy(part, file); err != nil {
+			panic(fmt.Errorf("writing file %q: %v", name, err))
+		}
+	}
+	if err := mw.Close(); err != nil {
+		panic(fmt.Errorf("closing multipart writer: %v", err))
+	}
+
+	req := w.NewRequest(method, url, &body)
+	req.Header.Set("Content-Type", mw.FormDataContentType())
 	return req
 }
 
+// NewQueryRequest is a helper method that builds a request without body, with the parameters encoded in the query string.
+// The parameters are appended to the query the path may already have.
+func (w *Wisent) NewQueryRequest(method string, path string, params url.Values) *http.Request {
+	u, err := url.Parse(path)
+	if err != nil {
+		panic(fmt.Errorf("parsing path: %v", err))
+	}
+	if query := params.Encode(); query != "" {
+		if u.RawQuery != "" {
+			u.RawQuery += "&"
+		}
+		u.RawQuery += query
+	}
+	return w.NewRequest(method, u.String(), nil)
+}
+
+// NewDeleteRequest is a helper method that builds a DELETE request without body, like NewRequest.
+func (w *Wisent) NewDeleteRequest(url string) *http.Request {
+	return w.NewRequest(http.MethodDelete, url, nil)
+}
+
+// NewPutRequest is a helper method that builds a PUT request, like NewRequest.
+func (w *Wisent) NewPutRequest(url string, body io.Reader) *http.Request {
+	return w.NewRequest(http.MethodPut, url, body)
+}
+
+// NewPatchRequest is a helper method that builds a PATCH request, like NewRequest.
+func (w *Wisent) NewPatchRequest(url string, body io.Reader) *http.Request {
+	return w.NewRequest(http.MethodPatch, url, body)
+}
+
+// NewHeadRequest is a helper method that builds a HEAD request, like NewRequest.
+func (w *Wisent) NewHeadRequest(url string) *http.Request {
+	return w.NewRequest(http.MethodHead, url, nil)
+}
+
+// applyBaseQueryParams appends base query parameters that are not already present in the request URL.
+// The existing query is kept as it is, so its encoding and order are preserved.
+func (w *Wisent) applyBaseQueryParams(req *http.Request) {
+	if len(w.baseQueryParams) == 0 {
+		return
+	}
+	query := req.URL.Query()
+	missing := url.Values{}
+	for key, values := range w.baseQueryParams {
+		if !query.Has(key) {
+			missing[key] = values
+		}
+	}
+	if len(missing) == 0 {
+		return
+	}
+	if req.URL.RawQuery != "" {
+		req.URL.RawQuery += "&"
+	}
+	req.URL.RawQuery += missing.Encode()
+}
+
+// do performs the request, using the request wrapper if one is configured.
+func (w *Wisent) do(req *http.Request) (*http.Response, error) {
+	if req.Header == nil && len(w.defaultHeaders) > 0 {
+		req.Header = http.Header{}
+	}
+	for key, values := range w.defaultHeaders {
+		if _, ok := req.Header[key]; !ok {
+			req.Header[key] = slices.Clone(values)
+		}
+	}
+
+	if w.contentLengthFix {
+		body, err := bufferRequestBody(req)
+		if err != nil {
+			return nil, err
+		}
+		req.ContentLength = int64(len(body))
+		if len(body) == 0 {
+			req.Body, req.GetBody = http.NoBody, nil
+		}
+	}
+
+	var bodyHash string
+	if w.auditLog != nil || w.onDuplicate != nil {
+		var err error
+		if bodyHash, err = hashRequestBody(req); err != nil {
+			return nil, err
+		}
+	}
+
+	if w.onDuplicate != nil && req.Context().Value(retryKey{}) == nil {
+		key := req.Method + " " + req.URL.String() + " " + bodyHash
+		if _, loaded := w.sentRequests.LoadOrStore(key, struct{}{}); loaded {
+			w.Logger.Warn("Duplicate request detected", "method", req.Method, "url", req.URL.String())
+			w.onDuplicate(req)
+		}
+	}
+
+	var harBody []byte
+	if w.harLog != nil {
+		var err error
+		if harBody, err = bufferRequestBody(req); err != nil {
+			return nil, err
+		}
+	}
+
+	var record auditRecord
+	if w.auditLog != nil {
+		record = auditRecord{Timestamp: time.Now(), Method: req.Method, URL: req.URL.String(), RequestBodyHash: bodyHash}
+	}
+	started := time.Now()
+
+	var resp *http.Response
+	var err error
+	if w.RequestWrapper != nil {
+		resp, err = w.RequestWrapper(w, req)
+	} else {
+		w.Logger.Info("Performing the request")
+		resp, err = w.HttpClient.Do(req)
+	}
+
+	if err == nil {
+		resp, err = w.wrapResponseBody(resp)
+	}
+
+	// The audit log and HAR capture read the whole body, so they come after the size limit.
+	if w.auditLog != nil {
+		w.audit(record, resp, err)
+	}
+	if w.harLog != nil {
+		w.harLog.record(started, req, harBody, resp, err)
+	}
+	return resp, err
+}
+
+// wrapResponseBody wraps the response body with the configured metrics, decoder and size limit.
+func (w *Wisent) wrapResponseBody(resp *http.Response) (*http.Response, error) {
+	if w.bodySizeStats != nil && w.sampled() {
+		resp.Body = &countingBody{ReadCloser: resp.Body, record: w.bodySizeStats.record}
+	}
+	if w.responseDecoder != nil {
+		body, err := w.responseDecoder(resp.Body)
+		if err != nil {
+			resp.Body.Close()
+			return nil, fmt.Errorf("decoding response body: %w", err)
+		}
+		resp.Body = body
+	}
+	if w.maxResponseBodySize > 0 {
+		size := "unknown"
+		if resp.ContentLength >= 0 {
+			size = strconv.FormatInt(resp.ContentLength, 10)
+		}
+		resp.Body = &limitedBody{ReadCloser: resp.Body, remaining: w.maxResponseBodySize, onTruncate: func() {
+			w.Logger.Warn("Response body truncated", "size", size, "max", w.maxResponseBodySize)
+		}}
+	}
+	return resp, nil
+}
+
+// audit completes the audit record with the outcome of the request and writes it.
+func (w *Wisent) audit(record auditRecord, resp *http.Response, err error) {
+	record.LatencyMs = float64(time.Since(record.Timestamp).Microseconds()) / 1000
+	if err != nil {
+		record.Error = err.Error()
+	} else {
+		record.StatusCode = resp.StatusCode
+		body, readErr := readBody(resp)
+		if readErr != nil {
+			record.Error = readErr.Error()
+		}
+		record.ResponseBodyHash = hashBytes(body)
+	}
+	if err := w.auditLog.write(record); err != nil {
+		w.Logger.Warn("Error writing audit record", "err", err)
+	}
+}
+
+// sampled decides whether a metric sample should be recorded, according to the sampling rate.
+func (w *Wisent) sampled() bool {
+	return w.samplingRate <= 0 || w.samplingRate >= 1 || rand.Float64() < w.samplingRate
+}
+
+// resetRun discards metrics collected before a benchmark resets its timer, e.g. in previous rounds of b.N.
+func (w *Wisent) resetRun() {
+	if w.bodySizeStats != nil {
+		w.bodySizeStats.reset()
+	}
+}
+
+// finishRun summarizes metrics collected during a test or benchmark run.
+func (w *Wisent) finishRun() {
+	if w.bodySizeStats != nil {
+		w.bodySizeStats.summarize()
+	}
+}
+
+// startApp starts the application under test, if configured, and waits for it to be ready.
+// It returns the context the application runs with and a function that shuts the application down.
+func (w *Wisent) startApp() (context.Context, func()) {
+	baseCtx := w.baseCtx
+	if baseCtx == nil {
+		baseCtx = context.Background()
+	}
+	var ctx context.Context
+	var cancel context.CancelFunc
+	if w.deadline.IsZero() {
+		ctx, cancel = context.WithCancel(baseCtx)
+	} else {
+		ctx, cancel = context.WithDeadline(baseCtx, w.deadline)
+	}
+
+	shutdown := func(context.Context) {}
+	if w.Start != nil {
+		w.Logger.Info("Starting the app")
+		shutdown = w.Start(ctx)
+	}
+
+	if w.ReadinessProbe != nil {
+		probe := func() {
+			w.Logger.Info("Starting the readiness probe")
+			if err := w.ReadinessProbe(ctx, w); err != nil {
+				w.Logger.Error("Readiness probe failed", "err", err)
+			}
+		}
+		if w.readinessProbeOnce {
+			w.readinessProbeDone.Do(probe)
+		} else {
+			probe()
+		}
+	}
+
+	return ctx, func() {
+		if w.Start != nil {
+			w.Logger.Info("Shutting down")
+		}
+		cancel()
+		shutdown(context.Background())
+	}
+}
+
 // Test runs a series of tests against the configured API.
 // It takes a testing.T instance and a slice of Test structs.
 // For each Test, it executes the HTTP request and runs the associated assertions.
+// If any test is parallel, the app is shut down only after the parallel tests complete,
+// which happens after the calling test function returns.
 func (w *Wisent) Test(t *testing.T, tests []Test) error {
 	w.Logger.Info("Starting tests")
-	ctx, cancel := context.WithCancel(context.Background())
+	ctx, stop := w.startApp()
+	cancel := func() {}
+	if w.maxTestDuration > 0 {
+		ctx, cancel = context.WithTimeout(ctx, w.maxTestDuration)
+	}
+	done := func() {
+		cancel()
+		w.finishRun()
+		w.Logger.Info("Testing done")
+		stop()
+	}
+	if slices.ContainsFunc(tests, func(tt Test) bool { return tt.Parallel }) {
+		t.Cleanup(done)
+	} else {
+		defer done()
+	}
 
-	if w.Start != nil {
-		w.Logger.Info("Starting the app")
-		shutdown := w.Start(ctx)
+	w.runTests(ctx, t, tests)
+	if errors.Is(ctx.Err(), context.DeadlineExceeded) {
+		return fmt.Errorf("running tests: %w", ctx.Err())
+	}
+	return nil
+}
+
+// TestParallel runs a series of tests like Test, but with every test marked as parallel.
+// The app is started once before any test runs, and shut down with t.Cleanup once all of them complete.
+func (w *Wisent) TestParallel(t *testing.T, tests []Test) error {
+	tests = slices.Clone(tests)
+	for i := range tests {
+		tests[i].Parallel = true
+	}
+	return w.Test(t, tests)
+}
+
+// RunTests runs a series of tests against the configured API, like Test, but without returning an error.
+func (w *Wisent) RunTests(t *testing.T, tests []Test) {
+	if err := w.Test(t, tests); err != nil {
+		t.Fatalf("Error running tests: %v", err)
+	}
+}
+
+// RunSuite runs a suite of tests against the configured API.
+// The app is started once for the whole suite. Suite setup runs before the first test,
+// and suite teardown runs after the last one, even if some tests failed.
+func (w *Wisent) RunSuite(t *testing.T, suite TestSuite) (err error) {
+	w.Logger.Info("Starting the suite")
+	ctx, stop := w.startApp()
+	defer stop()
+
+	if suite.Setup != nil {
+		w.Logger.Info("Running the suite setup")
+		if err := suite.Setup(w); err != nil {
+			return fmt.Errorf("running suite setup: %w", err)
+		}
+	}
+	if suite.Teardown != nil {
 		defer func() {
-			w.Logger.Info("Shutting down")
-			cancel()
-			shutdown(context.Background())
+			w.Logger.Info("Running the suite teardown")
+			if teardownErr := suite.Teardown(w); teardownErr != nil {
+				err = errors.Join(err, fmt.Errorf("running suite teardown: %w", teardownErr))
+			}
 		}()
+	}
+
+	// Parallel tests would outlive the suite teardown, so the suite runs all tests sequentially.
+	tests := slices.Clone(suite.Tests)
+	for i := range tests {
+		tests[i].Parallel = false
+	}
+	w.runTests(ctx, t, tests)
+
+	w.finishRun()
+	w.Logger.Info("Suite done")
+	return nil
+}
+
+// RunSequential runs a chain of dependent tests against the configured API.
+// Tests run in order and share the state of the sequence, which is updated by their StateTransform functions.
+// If any test fails, the remaining tests are skipped and an error is returned.
+func (w *Wisent) RunSequential(t *testing.T, seq SequentialTest) error {
+	w.Logger.Info("Starting the sequence")
+	ctx, stop := w.startApp()
+	defer stop()
+
+	state := seq.State
+	if state == nil {
+		state = State{}
+	}
+
+	logDuplicateNames(t, seq.Tests)
+
+	var failed string
+	parent := t
+	for _, tt := range seq.Tests {
+		tt.Parallel = false
+		passed := t.Run(tt.Name, func(t *testing.T) {
+			if failed != "" {
+				t.Skipf("Previous step %q failed", failed)
+			}
+			w.runTest(ctx, parent, t, tt, state)
+		})
+		if failed == "" && !passed {
+			failed = tt.Name
+		}
+	}
+
+	w.finishRun()
+	w.Logger.Info("Sequence done")
+	if failed != "" {
+		return fmt.Errorf("step %q failed", failed)
+	}
+	return nil
+}
+
+// runTests runs the tests as subtests, stopping early if the fail-fast limit is reached.
+func (w *Wisent) runTests(ctx context.Context, t *testing.T, tests []Test) {
+	logDuplicateNames(t, tests)
+
+	failures := 0
+	for i, tt := range tests {
+		if errors.Is(ctx.Err(), context.DeadlineExceeded) {
+			t.Errorf("Deadline exceeded, %d tests not run", len(tests)-i)
+			return
+		}
+		if !t.Run(tt.Name, func(st *testing.T) { w.runTest(ctx, t, st, tt, nil) }) {
+			failures++
+		}
+		if w.failFastAfter > 0 && failures >= w.failFastAfter {
+			w.Logger.Info("Stopping after failures", "failures", failures)
+			return
+		}
+	}
+}
+
+// logDuplicateNames logs names used by more than one test, which testing.T would silently suffix with #01, #02 etc.
+func logDuplicateNames(t *testing.T, tests []Test) {
+	seen := make(map[string]bool, len(tests))
+	for _, tt := range tests {
+		if seen[tt.Name] {
+			t.Logf("duplicate test name: %s", tt.Name)
+		}
+		seen[tt.Name] = true
+	}
+}
+
+// runTest runs a single test case as a subtest.
+// Assertions made on the parent test, which AssertResponse functions usually close over, are reported to the subtest,
+// unless the test is parallel, as parallel tests sharing the parent cannot be told apart.
+// The state is passed to the state transform of the test, and is nil outside of sequential tests.
+func (w *Wisent) runTest(ctx context.Context, parent testing.TB, t *testing.T, tt Test, state State) {
+	rec := &recordingTB{TB: t}
+	if tt.report != nil {
+		start := time.Now()
+		t.Cleanup(func() { tt.report(t, time.Since(start), rec.messages()) })
+	}
+	if reason := w.skipReason(tt); reason != "" {
+		t.Skip(reason)
+	}
+	if tt.Skip != nil && tt.Skip() {
+		if tt.SkipReason != "" {
+			t.Log(tt.SkipReason)
+		}
+		t.SkipNow()
+	}
+	if tt.Parallel {
+		t.Parallel()
 	} else {
+		defer w.assertionRouter.route(parent, rec)()
+	}
+	if tt.Cleanup != nil {
+		t.Cleanup(tt.Cleanup)
+	}
+
+	w.Logger.Info("Running the test", "name", tt.Name)
+
+	if w.cloneClient {
+		w = w.withClonedClient()
+	}
+
+	if tt.Setup != nil {
+		if err := tt.Setup(w); err != nil {
+			rec.Fatalf("Error running test setup: %v", err)
+		}
+	}
+
+	if tt.Timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, tt.Timeout)
 		defer cancel()
 	}
+	req := tt.Request.WithContext(ctx)
 
-	if w.ReadinessProbe != nil {
-		w.Logger.Info("Starting the readiness probe")
-		w.ReadinessProbe(ctx, w)
+	if tt.PreRequest != nil {
+		tt.PreRequest(req)
 	}
 
-	for _, tt := range tests {
-		t.Run(tt.Name, func(t *testing.T) {
-			w.Logger.Info("Running the test", "name", tt.Name)
+	resp := w.performWithRetry(parent, rec, tt, req)
 
-			if tt.PreRequest != nil {
-				tt.PreRequest(tt.Request)
-			}
+	if tt.StateTransform != nil && state != nil {
+		tt.StateTransform(state, resp)
+	}
+
+	if resp != nil {
+		resp.Body.Close()
+	}
+	w.Logger.Info("Finished test", "name", tt.Name)
+}
 
-			var resp *http.Response
-			var err error
-			if w.RequestWrapper != nil {
-				resp, err = w.RequestWrapper(w, tt.Request)
-			} else {
-				w.Logger.Info("Performing the request")
-				resp, err = w.HttpClient.Do(tt.Request)
+// retryKey marks the context of retried requests, which are not reported as duplicates by WithRequestDedup.
+type retryKey struct{}
+
+// performWithRetry performs the request of the test and runs its assertions, retrying them according
+// to the retry settings of the test. Assertions of attempts that may still be retried are recorded quietly,
+// so only the last attempt is reported. Assertions of parallel tests cannot be recorded, so only their requests are retried.
+func (w *Wisent) performWithRetry(parent testing.TB, rec *recordingTB, tt Test, req *http.Request) *http.Response {
+	retryIf := tt.RetryIf
+	if retryIf == nil {
+		retryIf = func(_ *http.Response, err error) bool { return err != nil }
+	}
+
+	attempts := max(tt.Retry, 0) + 1
+	start := time.Now()
+	var resp *http.Response
+	var err error
+	for i := range attempts {
+		last := i == attempts-1
+		if i > 0 {
+			if resp != nil {
+				resp.Body.Close()
 			}
+			select {
+			case <-req.Context().Done():
+				resp, err = nil, req.Context().Err()
+				last = true
+			case <-time.After(tt.RetryDelay):
+				if err := rewindBody(req); err != nil {
+					rec.Fatalf("Error retrying test %q: %v", tt.Name, err)
+				}
+				if i == 1 {
+					req = req.WithContext(context.WithValue(req.Context(), retryKey{}, true))
+				}
+				w.Logger.Debug("Performing the test request", "name", tt.Name, "attempt", i+1, "attempts", attempts)
+				resp, err = w.do(req)
+			}
+		} else {
+			w.Logger.Debug("Performing the test request", "name", tt.Name, "attempt", i+1, "attempts", attempts)
+			resp, err = w.do(req)
+		}
+		if tt.Timeout > 0 && errors.Is(err, context.DeadlineExceeded) {
+			rec.Fatalf("Test %q exceeded its timeout of %v", tt.Name, tt.Timeout)
+		}
 
-			if tt.PostRequest != nil {
-				tt.PostRequest(resp)
+		if retryIf(resp, err) {
+			if !last {
+				continue
+			}
+			if tt.Retry > 0 {
+				status := "error: " + fmt.Sprint(err)
+				if err == nil {
+					status = "status code: " + strconv.Itoa(resp.StatusCode)
+				}
+				rec.Errorf("Test %q still failing after %d attempts, last %s", tt.Name, i+1, status)
 			}
+		}
 
-			tt.AssertResponse(resp, err)
+		if tt.Retry <= 0 || tt.Parallel {
+			w.assert(rec, tt, resp, err, time.Since(start))
+			return resp
+		}
+		attempt := &recordingTB{TB: rec, quiet: true}
+		if w.assertQuietly(parent, attempt, func() { w.assert(attempt, tt, resp, err, time.Since(start)) }) {
+			return resp
+		}
+		if last {
+			if resp != nil {
+				resp.Body.Close()
+			}
+			rec.Fatalf("Test %q still failing after %d attempts, last assertion failure: %s", tt.Name, i+1, strings.Join(attempt.messages(), "\n"))
+		}
+		w.Logger.Debug("Assertions failed, retrying the test", "name", tt.Name, "attempt", i+1, "failures", attempt.messages())
+	}
+	return resp
+}
 
+// assert runs the assertions of a test on the response, reporting failures of wisent itself to tb.
+func (w *Wisent) assert(tb testing.TB, tt Test, resp *http.Response, err error, latency time.Duration) {
+	if w.successStatus != nil && err == nil {
+		if resp.StatusCode < w.successStatus[0] || resp.StatusCode > w.successStatus[1] {
 			resp.Body.Close()
-			w.Logger.Info("Finished test", "name", tt.Name)
-		})
+			tb.Fatalf("Unsuccessful status code, got: %v, want: [%v, %v]", resp.StatusCode, w.successStatus[0], w.successStatus[1])
+		}
 	}
 
-	w.Logger.Info("Testing done")
-	return nil
+	if tt.PostRequest != nil {
+		tt.PostRequest(resp)
+	}
+
+	tt.AssertResponse(resp, err)
+	if tt.AssertResponseLatency != nil {
+		tt.AssertResponseLatency(resp, err, latency)
+	}
+}
+
+// assertQuietly runs the assertions with those made on the parent test routed to a quiet recorder,
+// and reports whether they passed.
+func (w *Wisent) assertQuietly(parent testing.TB, rec *recordingTB, assert func()) (passed bool) {
+	defer w.assertionRouter.route(parent, rec)()
+	defer func() {
+		if r := recover(); r != nil {
+			if r != errAssertionStopped {
+				panic(r)
+			}
+			passed = false
+		}
+	}()
+	assert()
+	return !rec.Failed()
+}
+
+// withClonedClient returns a copy of the instance with a copy of the HTTP client, which has a fresh cookie jar.
+func (w *Wisent) withClonedClient() *Wisent {
+	client := *w.HttpClient
+	// cookiejar.New never fails without options.
+	client.Jar, _ = cookiejar.New(nil)
+	clone := *w
+	clone.HttpClient = &client
+	return &clone
+}
+
+// skipReason returns why the test should be skipped based on its tags, or an empty string if it should run.
+func (w *Wisent) skipReason(tt Test) string {
+	if len(w.tags) > 0 && !slices.ContainsFunc(tt.Tags, func(tag string) bool { return slices.Contains(w.tags, tag) }) {
+		return fmt.Sprintf("none of tags %v matches %v", tt.Tags, w.tags)
+	}
+	for _, tag := range tt.Tags {
+		if slices.Contains(w.skipTags, tag) {
+			return fmt.Sprintf("tag %q is skipped", tag)
+		}
+	}
+	return ""
 }
 
 // Benchmark runs a benchmark test against the configured API.
 // It takes a testing.B instance and a Benchmark struct.
 // For each iteration, it executes the HTTP request and runs the associated assertions.
 // The benchmark measures the performance of the API under test.
-func (w *Wisent) Benchmark(b *testing.B, bm Benchmark) error {
+// It returns the metrics collected during the run, and the last request error encountered, if any.
+func (w *Wisent) Benchmark(b *testing.B, bm Benchmark) (*BenchmarkResult, error) {
 	w.Logger.Info("Starting the benchmark")
-	ctx, cancel := context.WithCancel(context.Background())
+	_, stop := w.startApp()
+	defer stop()
 
-	if w.Start != nil {
-		w.Logger.Info("Starting the app")
-
-		shutdown := w.Start(ctx)
-		defer func() {
-			w.Logger.Info("Shutting down")
-			cancel()
-			shutdown(ctx)
-		}()
+	result := &BenchmarkResult{Name: w.benchmarkName, sampled: w.sampled}
 
-	} else {
-		defer cancel()
-	}
-
-	if w.ReadinessProbe != nil {
-		w.Logger.Info("Starting the readiness probe")
-		w.ReadinessProbe(ctx, w)
+	if bm.Bytes > 0 {
+		b.SetBytes(bm.Bytes)
 	}
 
+	w.warmup(b, bm)
 	b.ResetTimer()
+	result.started = time.Now()
+	w.resetRun()
 
 	for i := 0; i < b.N; i++ {
-		w.Logger.Info("Running the benchmark")
+		w.runBenchmarkIteration(bm, result)
+	}
 
-		req := bm.RequestF()
+	w.finishRun()
+	result.summarize()
+	w.Logger.Info("Benchmarking done")
+	return result, result.err()
+}
 
-		if bm.PreRequest != nil {
-			bm.PreRequest(req)
-		}
+// BenchmarkDuration runs a benchmark test like Benchmark, but keeps sending requests for the Duration
+// of the benchmark instead of b.N times, e.g. for steady-state measurements.
+// As testing.B may still call it multiple times, it is best run with -benchtime=1x.
+// The ns/op metric is reported per request sent.
+// It returns the metrics collected during the run, and the last request error encountered, if any.
+func (w *Wisent) BenchmarkDuration(b *testing.B, bm Benchmark) (*BenchmarkResult, error) {
+	if bm.Duration <= 0 {
+		return nil, errors.New("benchmark duration must be positive")
+	}
 
-		var resp *http.Response
-		var err error
-		if w.RequestWrapper != nil {
-			resp, err = w.RequestWrapper(w, req)
-		} else {
-			w.Logger.Info("Performing the request")
-			resp, err = w.HttpClient.Do(req)
-		}
+	w.Logger.Info("Starting the timed benchmark", "duration", bm.Duration)
+	_, stop := w.startApp()
+	defer stop()
 
-		if bm.PostRequest != nil {
-			bm.PostRequest(resp)
-		}
+	result := &BenchmarkResult{Name: w.benchmarkName, sampled: w.sampled}
 
-		bm.AssertResponse(resp, err)
+	if bm.Bytes > 0 {
+		b.SetBytes(bm.Bytes)
+	}
 
-		resp.Body.Close()
-		w.Logger.Info("Finished benchmark")
+	w.warmup(b, bm)
+	b.ResetTimer()
+	result.started = time.Now()
+	w.resetRun()
+
+	timeout := time.After(bm.Duration)
+loop:
+	for {
+		select {
+		case <-timeout:
+			break loop
+		default:
+			w.runBenchmarkIteration(bm, result)
+		}
 	}
 
+	b.StopTimer()
+	w.finishRun()
+	result.summarize()
+	if result.TotalRequests > 0 {
+		b.ReportMetric(float64(result.Duration.Nanoseconds())/float64(result.TotalRequests), "ns/op")
+	}
 	w.Logger.Info("Benchmarking done")
-	return nil
+	return result, result.err()
 }
 
 // BenchmarkParallel runs a parallel benchmark test against the configured API.
@@ -203,86 +923,195 @@ func (w *Wisent) Benchmark(b *testing.B, bm Benchmark) error {
 // For each goroutine, it repeatedly executes the HTTP request and runs the associated assertions.
 // The benchmark measures the performance of the API under test in a concurrent scenario.
 // This method is suitable for simulating high concurrency and measuring how the API performs under parallel load.
-func (w *Wisent) BenchmarkParallel(b *testing.B, bm Benchmark) error {
+// It returns the metrics collected during the run, and the last request error encountered, if any.
+func (w *Wisent) BenchmarkParallel(b *testing.B, bm Benchmark) (*BenchmarkResult, error) {
 	w.Logger.Info("Starting the parallel benchmark")
-	ctx, cancel := context.WithCancel(context.Background())
+	_, stop := w.startApp()
+	defer stop()
 
-	if w.Start != nil {
-		w.Logger.Info("Starting the app")
+	result := w.runBenchmarkParallel(b, bm)
 
-		shutdown := w.Start(ctx)
-		defer func() {
-			w.Logger.Info("Shutting down")
-			cancel()
-			shutdown(context.Background())
-		}()
-	} else {
-		defer cancel()
+	w.finishRun()
+	w.Logger.Info("Benchmarking done")
+	return result, result.err()
+}
+
+// BenchmarkParallelAB runs two parallel benchmarks as the "a" and "b" sub-benchmarks, for comparing implementations.
+// The app is started once and shared by both of them.
+// Like the other benchmark methods, it returns the metrics collected during each run, so that they can be compared,
+// e.g. with BenchmarkResult.Compare, and the last request errors encountered, if any.
+// The result of a sub-benchmark that was filtered out with -bench is nil.
+func (w *Wisent) BenchmarkParallelAB(b *testing.B, a, bBenchmark Benchmark) (*BenchmarkResult, *BenchmarkResult, error) {
+	w.Logger.Info("Starting the A/B benchmark")
+	_, stop := w.startApp()
+	defer stop()
+
+	var resultA, resultB *BenchmarkResult
+	b.Run("a", func(b *testing.B) { resultA = w.runBenchmarkParallel(b, a) })
+	b.Run("b", func(b *testing.B) { resultB = w.runBenchmarkParallel(b, bBenchmark) })
+
+	w.finishRun()
+	w.Logger.Info("Benchmarking done")
+
+	var errs []error
+	if resultA != nil && resultA.err() != nil {
+		errs = append(errs, fmt.Errorf("benchmark a: %w", resultA.err()))
+	}
+	if resultB != nil && resultB.err() != nil {
+		errs = append(errs, fmt.Errorf("benchmark b: %w", resultB.err()))
 	}
+	return resultA, resultB, errors.Join(errs...)
+}
 
-	if w.ReadinessProbe != nil {
-		w.Logger.Info("Starting the readiness probe")
-		w.ReadinessProbe(ctx, w)
+// runBenchmarkParallel runs the benchmark iterations in parallel, and returns the summarized result.
+func (w *Wisent) runBenchmarkParallel(b *testing.B, bm Benchmark) *BenchmarkResult {
+	result := &BenchmarkResult{Name: w.benchmarkName, sampled: w.sampled}
+
+	parallelism := 1
+	if bm.Concurrency > 0 {
+		parallelism = max(bm.Concurrency/runtime.GOMAXPROCS(0), 1)
+		b.SetParallelism(parallelism)
+	}
+	if bm.Bytes > 0 {
+		b.SetBytes(bm.Bytes)
+	}
+
+	// RunParallel starts parallelism*GOMAXPROCS goroutines, which wait for each other with the barrier enabled.
+	var ready sync.WaitGroup
+	if w.benchmarkBarrier {
+		ready.Add(parallelism * runtime.GOMAXPROCS(0))
 	}
 
+	w.warmup(b, bm)
 	b.ResetTimer()
+	result.started = time.Now()
+	w.resetRun()
 
 	b.RunParallel(func(pb *testing.PB) {
+		if w.benchmarkBarrier {
+			ready.Done()
+			ready.Wait()
+		}
 		for pb.Next() {
-			w.Logger.Info("Running the benchmark")
+			w.runBenchmarkIteration(bm, result)
+		}
+	})
 
-			req := bm.RequestF()
+	result.summarize()
+	return result
+}
 
-			if bm.PreRequest != nil {
-				bm.PreRequest(req)
-			}
+// BenchmarkParallelN runs a parallel benchmark test like BenchmarkParallel, but with n goroutines per GOMAXPROCS,
+// as set with testing.B.SetParallelism. The Concurrency field of the benchmark is ignored.
+func (w *Wisent) BenchmarkParallelN(b *testing.B, bm Benchmark, n int) (*BenchmarkResult, error) {
+	bm.Concurrency = n * runtime.GOMAXPROCS(0)
+	return w.BenchmarkParallel(b, bm)
+}
 
-			var resp *http.Response
-			var err error
-			if w.RequestWrapper != nil {
-				resp, err = w.RequestWrapper(w, req)
-			} else {
-				w.Logger.Info("Performing the request")
-				resp, err = w.HttpClient.Do(req)
-			}
+// BenchmarkParallelWithPool runs a parallel benchmark test, scheduling iterations on the provided worker pool
+// instead of goroutines managed by testing.B. It submits b.N iterations and waits for all of them to complete.
+// As iterations run outside of the benchmark goroutine, AssertResponse must not call b.FailNow or b.Fatal.
+// It returns the metrics collected during the run, and the first submission error or the last request error, if any.
+func (w *Wisent) BenchmarkParallelWithPool(b *testing.B, bm Benchmark, pool WorkerPool) (*BenchmarkResult, error) {
+	w.Logger.Info("Starting the pooled benchmark")
+	_, stop := w.startApp()
+	defer stop()
 
-			if bm.PostRequest != nil {
-				bm.PostRequest(resp)
-			}
+	result := &BenchmarkResult{Name: w.benchmarkName, sampled: w.sampled}
+	var wg sync.WaitGroup
 
-			bm.AssertResponse(resp, err)
+	if bm.Bytes > 0 {
+		b.SetBytes(bm.Bytes)
+	}
 
-			resp.Body.Close()
-			w.Logger.Info("Finished benchmark")
+	w.warmup(b, bm)
+	b.ResetTimer()
+	result.started = time.Now()
+	w.resetRun()
+
+	var submitErr error
+	for i := 0; i < b.N; i++ {
+		wg.Add(1)
+		if submitErr = pool.Submit(func() {
+			defer wg.Done()
+			w.runBenchmarkIteration(bm, result)
+		}); submitErr != nil {
+			wg.Done()
+			break
 		}
-	})
+	}
+	wg.Wait()
 
+	w.finishRun()
+	result.summarize()
 	w.Logger.Info("Benchmarking done")
-	return nil
+	if submitErr != nil {
+		return result, fmt.Errorf("submitting iteration: %w", submitErr)
+	}
+	return result, result.err()
 }
 
-// AssertResponseError is a testing helper method that checks if response error is empty.
-func (w *Wisent) AssertResponseError(tb testing.TB, err error) {
-	if err != nil {
-		tb.Fatalf("Error performing the request: %v", err)
+// warmup performs the warmup iterations of the benchmark, without recording them or asserting responses.
+// Requests are not counted in body size metrics, the audit log, HAR capture or deduplication.
+//
+// testing.B runs the benchmark function once per calibration round of b.N. An app started with StartFunc
+// is fresh in every round, so it is warmed up every time, while an external one is only warmed up once
+// per instance, benchmark and base URL.
+func (w *Wisent) warmup(b *testing.B, bm Benchmark) {
+	if bm.WarmupIterations <= 0 {
+		return
 	}
-}
+	if w.Start == nil && w.warmedUp != nil {
+		if _, done := w.warmedUp.LoadOrStore(w.BaseURL+" "+b.Name(), true); done {
+			w.Logger.Debug("Skipping warmup, already done", "benchmark", b.Name())
+			return
+		}
+	}
+
+	quiet := *w
+	quiet.onDuplicate, quiet.auditLog, quiet.harLog, quiet.bodySizeStats = nil, nil, nil, nil
+	for i := range bm.WarmupIterations {
+		w.Logger.Debug("Running warmup iteration", "iteration", i+1, "total", bm.WarmupIterations)
 
-// AssertResponseStatusCode is a testing helper method that compares response status code.
-func (w *Wisent) AssertResponseStatusCode(tb testing.TB, expected int, resp *http.Response) {
-	if resp.StatusCode != expected {
-		tb.Fatalf("Incorrect status code, got: %v, want: %v", resp.StatusCode, expected)
+		req := bm.RequestF()
+		if bm.PreRequest != nil {
+			bm.PreRequest(req)
+		}
+		resp, err := quiet.do(req)
+		if err != nil {
+			w.Logger.Debug("Error in warmup iteration", "err", err)
+		}
+		if bm.PostRequest != nil {
+			bm.PostRequest(resp)
+		}
+		if resp != nil {
+			resp.Body.Close()
+		}
 	}
 }
 
-// AssertResponseBody is a testing helper method that compares response body.
-func (w *Wisent) AssertResponseBody(tb testing.TB, expected string, resp *http.Response) {
-	actualBody, err := io.ReadAll(resp.Body)
-	if err != nil {
-		tb.Fatalf("Error reading response body: %v", err)
+// runBenchmarkIteration performs a single benchmark request and records it in the result.
+func (w *Wisent) runBenchmarkIteration(bm Benchmark, result *BenchmarkResult) {
+	w.Logger.Info("Running the benchmark")
+
+	req := bm.RequestF()
+
+	if bm.PreRequest != nil {
+		bm.PreRequest(req)
 	}
 
-	if string(actualBody) != expected {
-		tb.Fatalf("Body mismatch\nExpected: %s\nActual: %s", expected, actualBody)
+	start := time.Now()
+	resp, err := w.do(req)
+	result.record(time.Since(start), err)
+
+	if bm.PostRequest != nil {
+		bm.PostRequest(resp)
+	}
+
+	bm.AssertResponse(resp, err)
+
+	if resp != nil {
+		resp.Body.Close()
 	}
+	w.Logger.Info("Finished benchmark")
 }
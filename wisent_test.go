@@ -0,0 +1,318 @@
+package wisent_test
+
+import (
+	"compress/gzip"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+
+	"github.com/ttyobiwan/wisent"
+)
+
+func TestRunSequential(t *testing.T) {
+	mux := http.NewServeMux()
+	mux.HandleFunc("POST /items", func(w http.ResponseWriter, _ *http.Request) {
+		w.WriteHeader(http.StatusCreated)
+		w.Write([]byte(`{"id":"42"}`))
+	})
+	mux.HandleFunc("GET /items/{id}", func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(r.PathValue("id")))
+	})
+	server := httptest.NewServer(mux)
+	defer server.Close()
+
+	w := wisent.New(server.URL)
+	state := wisent.State{}
+
+	err := w.RunSequential(t, wisent.SequentialTest{
+		State: state,
+		Tests: []wisent.Test{
+			{
+				Name:    "create",
+				Request: w.NewRequest(http.MethodPost, "/items", nil),
+				AssertResponse: func(resp *http.Response, err error) {
+					w.AssertResponseError(t, err)
+					w.AssertResponseStatusCode(t, http.StatusCreated, resp)
+				},
+				StateTransform: func(state wisent.State, resp *http.Response) {
+					var body struct{ ID string }
+					json.NewDecoder(resp.Body).Decode(&body)
+					state["id"] = body.ID
+				},
+			},
+			{
+				Name:       "read",
+				Request:    w.NewRequest(http.MethodGet, "/items/", nil),
+				PreRequest: func(req *http.Request) { req.URL.Path += state["id"].(string) },
+				AssertResponse: func(resp *http.Response, err error) {
+					w.AssertResponseError(t, err)
+					w.AssertResponseBody(t, "42", resp)
+				},
+			},
+		},
+	})
+
+	if err != nil {
+		t.Fatalf("Error running sequence: %v", err)
+	}
+}
+
+func TestTestRetry(t *testing.T) {
+	requests := 0
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		requests++
+		if requests < 3 {
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	defer server.Close()
+
+	duplicates := 0
+	w := wisent.New(server.URL, wisent.WithRequestDedup(func(*http.Request) { duplicates++ }))
+	w.Test(t, []wisent.Test{
+		{
+			Name:    "eventually found",
+			Request: w.NewRequest(http.MethodGet, "/", nil),
+			Retry:   3,
+			RetryIf: func(resp *http.Response, err error) bool {
+				return err != nil || resp.StatusCode == http.StatusNotFound
+			},
+			AssertResponse: func(resp *http.Response, err error) {
+				w.AssertResponseError(t, err)
+				w.AssertResponseStatusCode(t, http.StatusOK, resp)
+			},
+		},
+	})
+
+	if requests != 3 {
+		t.Fatalf("Incorrect number of requests, got: %v, want: %v", requests, 3)
+	}
+	if duplicates != 0 {
+		t.Fatalf("Incorrect number of duplicates, got: %v, want: %v", duplicates, 0)
+	}
+}
+
+func TestTestParallel(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {}))
+	t.Cleanup(server.Close)
+
+	shutdown := false
+	w := wisent.New(server.URL, wisent.WithStartFunc(func(context.Context) func(context.Context) {
+		return func(context.Context) { shutdown = true }
+	}))
+
+	tests := make([]wisent.Test, 3)
+	for i := range tests {
+		tests[i] = wisent.Test{
+			Name:     fmt.Sprintf("parallel %d", i),
+			Request:  w.NewRequest(http.MethodGet, "/", nil),
+			Parallel: true,
+			AssertResponse: func(resp *http.Response, err error) {
+				if shutdown {
+					t.Errorf("App shut down before parallel test ran")
+				}
+				w.AssertResponseError(t, err)
+			},
+		}
+	}
+	w.Test(t, tests)
+}
+
+func TestNewInvalidOptions(t *testing.T) {
+	defer func() {
+		p := recover()
+		if p == nil {
+			t.Fatalf("Expected New to panic")
+		}
+		for _, want := range []string{"applying options", "parsing proxy url", `unsupported scheme "ftp"`} {
+			if !strings.Contains(fmt.Sprint(p), want) {
+				t.Fatalf("Incorrect panic, got: %v, want it to contain: %q", p, want)
+			}
+		}
+	}()
+	wisent.New("http://127.0.0.1", wisent.WithProxy("ftp://proxy"))
+}
+
+func TestResponseDecompression(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/gzip":
+			w.Header().Set("Content-Encoding", "gzip")
+			gw := gzip.NewWriter(w)
+			gw.Write([]byte("compressed"))
+			gw.Close()
+		case "/br":
+			w.Header().Set("Content-Encoding", "br")
+			w.Write([]byte("not really brotli"))
+		}
+	}))
+	defer server.Close()
+
+	w := wisent.New(server.URL, wisent.WithResponseDecompression())
+	w.Test(t, []wisent.Test{
+		{
+			Name:    "gzip",
+			Request: w.NewRequest(http.MethodGet, "/gzip", nil),
+			AssertResponse: func(resp *http.Response, err error) {
+				w.AssertResponseError(t, err)
+				w.AssertResponseNoHeader(t, "Content-Encoding", resp)
+				w.AssertResponseBody(t, "compressed", resp)
+			},
+		},
+		{
+			Name:    "brotli passed through",
+			Request: w.NewRequest(http.MethodGet, "/br", nil),
+			AssertResponse: func(resp *http.Response, err error) {
+				w.AssertResponseError(t, err)
+				w.AssertResponseHeader(t, "Content-Encoding", "br", resp)
+				w.AssertResponseBody(t, "not really brotli", resp)
+			},
+		},
+	})
+}
+
+func TestMaxResponseBodySize(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/chunked" {
+			w.Write([]byte("0123"))
+			w.(http.Flusher).Flush()
+		}
+		w.Write([]byte("456789"))
+	}))
+	defer server.Close()
+
+	var logs strings.Builder
+	logger := slog.New(slog.NewTextHandler(&logs, nil))
+	w := wisent.New(server.URL, wisent.WithMaxResponseBodySize(4), wisent.WithLogger(logger))
+	w.Test(t, []wisent.Test{
+		{
+			Name:    "known size",
+			Request: w.NewRequest(http.MethodGet, "/", nil),
+			AssertResponse: func(resp *http.Response, err error) {
+				w.AssertResponseError(t, err)
+				w.AssertResponseBody(t, "4567", resp)
+			},
+		},
+		{
+			Name:    "unknown size",
+			Request: w.NewRequest(http.MethodGet, "/chunked", nil),
+			AssertResponse: func(resp *http.Response, err error) {
+				w.AssertResponseError(t, err)
+				w.AssertResponseBody(t, "0123", resp)
+			},
+		},
+	})
+
+	for _, want := range []string{"size=6 max=4", "size=unknown max=4"} {
+		if !strings.Contains(logs.String(), want) {
+			t.Errorf("Truncation warning not logged, got: %s, want it to contain: %q", logs.String(), want)
+		}
+	}
+}
+
+type failingWriter struct{ after int }
+
+func (f *failingWriter) Write(p []byte) (int, error) {
+	if f.after <= 0 {
+		return 0, errors.New("disk full")
+	}
+	f.after--
+	return len(p), nil
+}
+
+func TestAuditLogWriteErrors(t *testing.T) {
+	t.Run("header", func(t *testing.T) {
+		defer func() {
+			if p := recover(); !strings.Contains(fmt.Sprint(p), "creating audit log: writing audit header: disk full") {
+				t.Fatalf("Incorrect panic, got: %v", p)
+			}
+		}()
+		wisent.New("http://127.0.0.1", wisent.WithAuditLog(&failingWriter{}, wisent.AuditFormatCSV))
+	})
+
+	t.Run("record", func(t *testing.T) {
+		server := httptest.NewServer(http.HandlerFunc(func(http.ResponseWriter, *http.Request) {}))
+		defer server.Close()
+		var logs strings.Builder
+		w := wisent.New(server.URL,
+			wisent.WithAuditLog(&failingWriter{after: 1}, wisent.AuditFormatCSV),
+			wisent.WithLogger(slog.New(slog.NewTextHandler(&logs, nil))),
+		)
+
+		w.Test(t, []wisent.Test{
+			{
+				Name:           "request",
+				Request:        w.NewRequest(http.MethodGet, "/", nil),
+				AssertResponse: func(_ *http.Response, err error) { w.AssertResponseError(t, err) },
+			},
+		})
+
+		if want := `msg="Error writing audit record" err="disk full"`; !strings.Contains(logs.String(), want) {
+			t.Fatalf("Audit error not logged, got: %s, want it to contain: %q", logs.String(), want)
+		}
+	})
+}
+
+func TestNewMultipartRequest(t *testing.T) {
+	w := wisent.New("http://127.0.0.1")
+	req := w.NewMultipartRequest(http.MethodPost, "/upload", map[string]string{"title": "report"}, map[string]io.Reader{
+		"plain":   strings.NewReader("a"),
+		"value":   wisent.MultipartFile{Reader: strings.NewReader("b"), Filename: "value.txt"},
+		"pointer": &wisent.MultipartFile{Reader: strings.NewReader("c"), Filename: "pointer.txt"},
+	})
+
+	if err := req.ParseMultipartForm(1 << 20); err != nil {
+		t.Fatalf("Error parsing multipart form: %v", err)
+	}
+	if got := req.FormValue("title"); got != "report" {
+		t.Fatalf("Incorrect field, got: %q, want: %q", got, "report")
+	}
+	for name, want := range map[string]string{"plain": "plain", "value": "value.txt", "pointer": "pointer.txt"} {
+		if got := req.MultipartForm.File[name][0].Filename; got != want {
+			t.Fatalf("Incorrect filename of %q, got: %q, want: %q", name, got, want)
+		}
+	}
+}
+
+func TestNewQueryRequest(t *testing.T) {
+	w := wisent.New("http://127.0.0.1")
+	tests := []struct {
+		path string
+		want string
+	}{
+		{"/items", "/items?q=a+b"},
+		{"/items?page=2", "/items?page=2&q=a+b"},
+		{"/items/a%2Fb?page=2", "/items/a%2Fb?page=2&q=a+b"},
+	}
+	for _, tt := range tests {
+		req := w.NewQueryRequest(http.MethodGet, tt.path, url.Values{"q": {"a b"}})
+		if got := req.URL.RequestURI(); got != tt.want {
+			t.Errorf("Incorrect request URI for %q, got: %q, want: %q", tt.path, got, tt.want)
+		}
+	}
+}
+
+func TestBaseQueryParams(t *testing.T) {
+	w := wisent.New("http://127.0.0.1", wisent.WithBaseQueryParams(url.Values{"api_key": {"secret"}, "page": {"1"}}))
+	tests := []struct {
+		path string
+		want string
+	}{
+		{"/items", "/items?api_key=secret&page=1"},
+		{"/items?z=1&page=2", "/items?z=1&page=2&api_key=secret"},
+		{"/items?filter=a%2Cb&api_key=other&page=3", "/items?filter=a%2Cb&api_key=other&page=3"},
+	}
+	for _, tt := range tests {
+		if got := w.NewRequest(http.MethodGet, tt.path, nil).URL.RequestURI(); got != tt.want {
+			t.Errorf("Incorrect request URI for %q, got: %q, want: %q", tt.path, got, tt.want)
+		}
+	}
+}